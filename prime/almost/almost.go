@@ -0,0 +1,258 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package almost computes k-almost-primes, numbers n with Ω(n) = k, where
+// Ω(n) is the number of prime factors of n counted with multiplicity.
+//
+// Primes are 1-almost-primes.  Semiprimes, OEIS A001358, are
+// 2-almost-primes.  A segmented sieve accumulates Ω for each number of
+// a block by dividing out prime powers p^e for primes p taken from a
+// prime.Generator.  A cofactor remaining after all primes up to the
+// square root have been divided out is one more prime factor.
+//
+// Count does not sieve.  It counts from tables of π, in time sublinear
+// in max.
+package almost
+
+import (
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/xmath"
+)
+
+// Sieve type holds the parameter k and a generator of sieving primes.
+//
+// Sieve iterates over k-almost-primes rather than primes, but otherwise
+// satisfies prime.Generator.
+type Sieve struct {
+	K   uint
+	Gen prime.Generator
+}
+
+// New constructs a Sieve for k-almost-primes, with sieving primes from g.
+//
+// New returns nil for k < 1.
+func New(k uint, g prime.Generator) *Sieve {
+	if k < 1 {
+		return nil
+	}
+	return &Sieve{k, g}
+}
+
+// Limit returns the largest value that can be sieved with primes from
+// the underlying generator, (L+1)² - 1 where L is the limit of the
+// generator.
+func (s *Sieve) Limit() uint64 {
+	l := s.Gen.Limit()
+	if l >= 1<<32-1 {
+		return 1<<64 - 1
+	}
+	return l*l + 2*l
+}
+
+// blockSize is the number of integers sieved at a time.
+const blockSize = 1 << 15
+
+// Iterate calls the visitor function for each k-almost-prime between min
+// and max inclusive.
+//
+// Iterate returns false if max > Limit().
+func (s *Sieve) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	return s.blocks(min, max, func(b0 uint64, omega []uint8) bool {
+		for j, o := range omega {
+			if uint(o) == s.K && visitor(b0+uint64(j)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// CountBlocks counts k-almost-primes between min and max inclusive by
+// sieving, counting Ω of each number.
+//
+// The ok result is false if max > Limit().
+func (s *Sieve) CountBlocks(min, max uint64) (n uint64, ok bool) {
+	ok = s.blocks(min, max, func(_ uint64, omega []uint8) bool {
+		for _, o := range omega {
+			if uint(o) == s.K {
+				n++
+			}
+		}
+		return false
+	})
+	return
+}
+
+// Count counts k-almost-primes between min and max inclusive.
+//
+// A k-almost-prime n <= x is p1·p2···pk with p1 <= p2 <= ... <= pk.  For
+// each choice of p1 through p(k-1), the number of choices of pk is
+// π(x/(p1···p(k-1))) - π(p(k-1)) + 1.  Every such quotient is ⌊x/d⌋ for
+// some d, and π is tabulated for all of these with the Lucy_Hedgehog
+// method.  This takes time proportional to x^(3/4) and space proportional
+// to x^(1/2), plus one term for each choice of p1 through p(k-1).
+//
+// The ok result is false if max > Limit().
+func (s *Sieve) Count(min, max uint64) (n uint64, ok bool) {
+	if max > s.Limit() {
+		return 0, false
+	}
+	if min < 1 {
+		min = 1
+	}
+	if min > max {
+		return 0, true
+	}
+	ps := prime.Primes(s.Gen, 2, xmath.FloorSqrt64(max))
+	n = s.count(max, ps)
+	if min > 1 {
+		n -= s.count(min-1, ps)
+	}
+	return n, true
+}
+
+// count returns the number of k-almost-primes <= x.  ps must hold the
+// primes up to √x.
+func (s *Sieve) count(x uint64, ps []uint64) uint64 {
+	t := newPiTable(x, ps)
+	return t.almost(x, s.K, 0, ps)
+}
+
+// almost returns the number of k-almost-primes <= y with no prime factor
+// less than ps[i].
+func (t *piTable) almost(y uint64, k uint, i int, ps []uint64) uint64 {
+	if k == 1 {
+		if n := t.pi(y); n > uint64(i) {
+			return n - uint64(i)
+		}
+		return 0
+	}
+	var n uint64
+	for j := i; j < len(ps); j++ {
+		// p^k <= y
+		q := y
+		for e := uint(0); e < k && q > 0; e++ {
+			q /= ps[j]
+		}
+		if q == 0 {
+			break
+		}
+		n += t.almost(y/ps[j], k-1, j, ps)
+	}
+	return n
+}
+
+// piTable holds π(v) for each v = ⌊x/d⌋.
+//
+// Values v <= r = ⌊√x⌋ are held in small[v], values v = ⌊x/i⌋ > r in
+// large[i].
+type piTable struct {
+	x, r         uint64
+	small, large []uint64
+}
+
+// newPiTable computes π(⌊x/d⌋) for all d with the Lucy_Hedgehog method.
+// ps must hold the primes up to √x.
+//
+// Starting from the count of 2..v, for each prime p in order, numbers
+// whose least prime factor is p are removed:  S(v) -= S(v/p) - S(p-1)
+// for v >= p².
+func newPiTable(x uint64, ps []uint64) *piTable {
+	r := xmath.FloorSqrt64(x)
+	t := &piTable{x, r, make([]uint64, r+1), make([]uint64, r+1)}
+	for v := uint64(1); v <= r; v++ {
+		t.small[v] = v - 1
+		t.large[v] = x/v - 1
+	}
+	for _, p := range ps {
+		if p > r {
+			break
+		}
+		sp := t.small[p-1]
+		p2 := p * p
+		for i := uint64(1); i <= r && i <= x/p2; i++ {
+			if d := i * p; d <= r {
+				t.large[i] -= t.large[d] - sp
+			} else {
+				t.large[i] -= t.small[x/d] - sp
+			}
+		}
+		for v := r; v >= p2; v-- {
+			t.small[v] -= t.small[v/p] - sp
+		}
+	}
+	return t
+}
+
+// pi returns π(v) for v = ⌊x/d⌋.
+func (t *piTable) pi(v uint64) uint64 {
+	if v <= t.r {
+		return t.small[v]
+	}
+	return t.large[t.x/v]
+}
+
+// Omega calls f with Ω(n) for each n between min and max inclusive.
+//
+// Omega returns false if max > Limit().
+func (s *Sieve) Omega(min, max uint64, f func(n uint64, omega uint) (terminate bool)) bool {
+	return s.blocks(min, max, func(b0 uint64, omega []uint8) bool {
+		for j, o := range omega {
+			if f(b0+uint64(j), uint(o)) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// blocks sieves [min, max] in blocks, calling f with the start of each
+// block and Ω for each number of the block.
+func (s *Sieve) blocks(min, max uint64, f func(b0 uint64, omega []uint8) bool) bool {
+	if max > s.Limit() {
+		return false
+	}
+	if min < 1 {
+		min = 1
+	}
+	if min > max {
+		return true
+	}
+	ps := prime.Primes(s.Gen, 2, xmath.FloorSqrt64(max))
+	var omega [blockSize]uint8
+	var rem [blockSize]uint64
+	for b0 := min; ; b0 += blockSize {
+		nb := max - b0 + 1
+		if nb > blockSize || nb == 0 {
+			nb = blockSize
+		}
+		for j := uint64(0); j < nb; j++ {
+			omega[j] = 0
+			rem[j] = b0 + j
+		}
+		bEnd := b0 + nb - 1
+		for _, p := range ps {
+			// each power of p dividing n adds 1 to Ω(n).
+			for pe := p; ; pe *= p {
+				for j := (pe - b0%pe) % pe; j < nb; j += pe {
+					omega[j]++
+					rem[j] /= p
+				}
+				if pe > bEnd/p {
+					break
+				}
+			}
+		}
+		for j := uint64(0); j < nb; j++ {
+			if rem[j] > 1 {
+				omega[j]++
+			}
+		}
+		if f(b0, omega[:nb]) {
+			return true
+		}
+		if max-b0 < blockSize {
+			return true
+		}
+	}
+}
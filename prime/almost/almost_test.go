@@ -0,0 +1,77 @@
+package almost_test
+
+import (
+	"testing"
+
+	"github.com/soniakeys/integer/prime/almost"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// OEIS A001358, semiprimes.
+var semiprimes = []uint64{4, 6, 9, 10, 14, 15, 21, 22, 25, 26, 33, 34, 35,
+	38, 39, 46, 49, 51, 55, 57, 58, 62, 65, 69, 74, 77, 82, 85, 86, 87, 91}
+
+func TestIterate(t *testing.T) {
+	var got []uint64
+	almost.New(2, sieve.New(100)).Iterate(0, 91, func(n uint64) bool {
+		got = append(got, n)
+		return false
+	})
+	if len(got) != len(semiprimes) {
+		t.Fatalf("semiprimes: %v", got)
+	}
+	for i, n := range semiprimes {
+		if got[i] != n {
+			t.Fatalf("semiprimes: %v", got)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	s := sieve.New(1e4)
+	big := sieve.New(1e5)
+	// primes, and OEIS A066265, number of semiprimes < 10^n.
+	for _, tc := range []struct {
+		k       uint
+		x, want uint64
+	}{
+		{1, 1e6, 78498},
+		{2, 1e6, 210035},
+		{2, 1e7, 1904324},
+		{1, 1e10, 455052511},
+		{2, 1e10, 1493776443},
+	} {
+		if n, ok := almost.New(tc.k, big).Count(1, tc.x); !ok || n != tc.want {
+			t.Errorf("Count k=%d (1, %d) = %d, want %d", tc.k, tc.x, n, tc.want)
+		}
+		if tc.x > 1e7 {
+			continue
+		}
+		if n, ok := almost.New(tc.k, s).CountBlocks(1, tc.x); !ok || n != tc.want {
+			t.Errorf("CountBlocks k=%d (1, %d) = %d, want %d",
+				tc.k, tc.x, n, tc.want)
+		}
+	}
+	for k := uint(1); k <= 5; k++ {
+		a := almost.New(k, s)
+		for _, w := range []struct{ min, max uint64 }{
+			{0, 1}, {1, 1e6}, {12345, 543210}, {999999, 1e6}, {2, 3},
+		} {
+			n1, _ := a.Count(w.min, w.max)
+			n2, _ := a.CountBlocks(w.min, w.max)
+			if n1 != n2 {
+				t.Errorf("k=%d (%d, %d): Count %d, CountBlocks %d",
+					k, w.min, w.max, n1, n2)
+			}
+		}
+	}
+	// every n > 1 has some Ω(n) > 0.
+	var total uint64
+	for k := uint(1); k < 64; k++ {
+		n, _ := almost.New(k, s).CountBlocks(99000, 101000)
+		total += n
+	}
+	if total != 101000-99000+1 {
+		t.Errorf("total over k = %d", total)
+	}
+}
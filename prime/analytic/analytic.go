@@ -0,0 +1,261 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package analytic computes functions from the analytic theory of primes,
+// for comparison with prime counts.
+//
+// Chebyshev functions θ(x) and ψ(x) are computed exactly, up to rounding
+// at a chosen precision, from primes of any prime.Generator.  The
+// logarithmic integral li(x) and Riemann's R(x) are computed from
+// convergent series, also at a chosen precision.
+//
+// All results are big.Float.
+package analytic
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/xmath"
+)
+
+// chunk is the number of factors multiplied as big.Int before rounding
+// to big.Float.
+const chunk = 256
+
+// product accumulates a product of uint64 factors as m·2^e, with m kept
+// as a big.Float to avoid overflowing the exponent range of big.Float.
+type product struct {
+	seq []uint64
+	m   big.Float
+	e   int64
+	z   big.Int
+	f   big.Float
+}
+
+func newProduct(wp uint) *product {
+	p := &product{seq: make([]uint64, 0, chunk)}
+	p.m.SetPrec(wp).SetInt64(1)
+	p.f.SetPrec(wp)
+	return p
+}
+
+func (p *product) mul(n uint64) {
+	if p.seq = append(p.seq, n); len(p.seq) == chunk {
+		p.flush()
+	}
+}
+
+func (p *product) flush() {
+	if len(p.seq) == 0 {
+		return
+	}
+	p.m.Mul(&p.m, p.f.SetInt(xmath.Product(&p.z, p.seq)))
+	p.seq = p.seq[:0]
+	p.e += int64(p.m.MantExp(&p.m))
+}
+
+// log returns the natural logarithm of the product, to prec bits.
+func (p *product) log(prec uint) *big.Float {
+	p.flush()
+	wp := p.m.Prec()
+	r := Log(&p.m, wp)
+	if p.e != 0 {
+		l2 := ln2(wp)
+		r.Add(r, l2.Mul(l2, new(big.Float).SetInt64(p.e)))
+	}
+	return r.SetPrec(prec)
+}
+
+// guard returns a working precision for prec bits of a sum of about n
+// rounded terms.
+func guard(prec uint, n uint64) uint {
+	return prec + 32 + uint(xmath.Log2(uint(n|1)))
+}
+
+// chebyshev computes π(x), θ(x), and ψ(x) in a single pass over primes.
+func chebyshev(g prime.Generator, x uint64, prec uint) (pi uint64, theta, psi *big.Float) {
+	wp := guard(prec, x)
+	th := newProduct(wp)
+	ps := newProduct(wp)
+	g.Iterate(2, x, func(p uint64) (terminate bool) {
+		pi++
+		th.mul(p)
+		// largest power of p <= x
+		pk := p
+		for pk <= x/p {
+			pk *= p
+		}
+		ps.mul(pk)
+		return
+	})
+	return pi, th.log(prec), ps.log(prec)
+}
+
+// Theta returns Chebyshev's function θ(x), the sum of ln p for primes
+// p <= x, computed to prec bits.
+//
+// θ(x) is computed as the logarithm of the product of primes, rounded to
+// prec bits plus guard bits in chunks of primes.
+//
+// Theta returns nil if x > g.Limit().
+func Theta(g prime.Generator, x uint64, prec uint) *big.Float {
+	if x > g.Limit() {
+		return nil
+	}
+	_, t, _ := chebyshev(g, x, prec)
+	return t
+}
+
+// Psi returns Chebyshev's function ψ(x), the sum of ln p for prime powers
+// p^k <= x, computed to prec bits.
+//
+// Psi returns nil if x > g.Limit().
+func Psi(g prime.Generator, x uint64, prec uint) *big.Float {
+	if x > g.Limit() {
+		return nil
+	}
+	_, _, p := chebyshev(g, x, prec)
+	return p
+}
+
+// Li returns the logarithmic integral li(x), the principal value of the
+// integral of 1/ln t from 0 to x, computed to prec bits.  If prec is 0,
+// the precision of x is used.
+//
+// Li returns nil if x <= 1.
+//
+// Li uses Ramanujan's series,
+//
+//	li(x) = γ + ln ln x + √x Σ (-1)^(n-1) (ln x)^n / (n! 2^(n-1))
+//	                         Σ_{k=0}^{⌊(n-1)/2⌋} 1/(2k+1)
+//
+// which converges for all x > 1.
+func Li(x *big.Float, prec uint) *big.Float {
+	if x.Cmp(big.NewFloat(1)) <= 0 {
+		return nil
+	}
+	if prec == 0 {
+		prec = x.Prec()
+	}
+	wp := prec + 64
+	l := Log(x, wp)
+	lf, _ := l.Float64()
+	// terms grow to about e^(ln x / 2) before they shrink.
+	wp += uint(lf / 2 / math.Ln2)
+	l = Log(x, wp)
+
+	sum := new(big.Float).SetPrec(wp)
+	term := new(big.Float).SetPrec(wp).SetInt64(-2) // (-1)^(n-1) (ln x)^n / (n! 2^(n-1))
+	inner := new(big.Float).SetPrec(wp)
+	var nf, q, t big.Float
+	nf.SetPrec(wp)
+	q.SetPrec(wp)
+	t.SetPrec(wp)
+	for n := int64(1); ; n++ {
+		term.Mul(term, l)
+		term.Quo(term, nf.SetInt64(-2*n))
+		if n&1 == 1 {
+			inner.Add(inner, q.Quo(q.SetInt64(1), nf.SetInt64(n)))
+		}
+		t.Mul(term, inner)
+		sum.Add(sum, &t)
+		if float64(n) > lf && t.MantExp(nil)-sum.MantExp(nil) < -int(wp) {
+			break
+		}
+	}
+	sum.Mul(sum, new(big.Float).SetPrec(wp).Sqrt(new(big.Float).SetPrec(wp).Set(x)))
+	sum.Add(sum, euler(wp))
+	sum.Add(sum, Log(l, wp))
+	return sum.SetPrec(prec)
+}
+
+// R returns Riemann's prime counting function R(x), computed to prec bits.
+// If prec is 0, the precision of x is used.
+//
+// R returns nil if x < 1.
+//
+// R uses the Gram series,
+//
+//	R(x) = 1 + Σ_{k>=1} (ln x)^k / (k · k! · ζ(k+1))
+func R(x *big.Float, prec uint) *big.Float {
+	if x.Cmp(big.NewFloat(1)) < 0 {
+		return nil
+	}
+	if prec == 0 {
+		prec = x.Prec()
+	}
+	wp := prec + 32
+	l := Log(x, wp)
+	lf, _ := l.Float64()
+	z := newZeta(wp)
+	sum := new(big.Float).SetPrec(wp).SetInt64(1)
+	term := new(big.Float).SetPrec(wp).SetInt64(1) // (ln x)^k / k!
+	var kf, t big.Float
+	kf.SetPrec(wp)
+	t.SetPrec(wp)
+	for k := int64(1); ; k++ {
+		term.Mul(term, l)
+		term.Quo(term, kf.SetInt64(k))
+		if term.Sign() == 0 {
+			break
+		}
+		t.Quo(term, kf.SetInt64(k))
+		t.Quo(&t, z.at(k+1))
+		sum.Add(sum, &t)
+		if float64(k) > lf && t.MantExp(nil)-sum.MantExp(nil) < -int(wp) {
+			break
+		}
+	}
+	return sum.SetPrec(prec)
+}
+
+// Report compares π(x) with θ(x), ψ(x), li(x), and R(x).
+type Report struct {
+	X     uint64
+	Pi    uint64     // π(x), the number of primes <= x
+	Theta *big.Float // θ(x)
+	Psi   *big.Float // ψ(x)
+	Li    *big.Float // li(x)
+	R     *big.Float // R(x)
+}
+
+// Compare computes a Report for x, with primes from g, to prec bits.
+//
+// Compare returns nil if x < 2 or x > g.Limit().
+func Compare(g prime.Generator, x uint64, prec uint) *Report {
+	if x < 2 || x > g.Limit() {
+		return nil
+	}
+	r := &Report{X: x}
+	r.Pi, r.Theta, r.Psi = chebyshev(g, x, prec)
+	xf := new(big.Float).SetUint64(x)
+	r.Li = Li(xf, prec)
+	r.R = R(xf, prec)
+	return r
+}
+
+// ThetaError returns θ(x) - x.
+func (r *Report) ThetaError() *big.Float {
+	return r.diff(r.Theta, r.X)
+}
+
+// PsiError returns ψ(x) - x.
+func (r *Report) PsiError() *big.Float {
+	return r.diff(r.Psi, r.X)
+}
+
+// LiError returns li(x) - π(x).
+func (r *Report) LiError() *big.Float {
+	return r.diff(r.Li, r.Pi)
+}
+
+// RError returns R(x) - π(x).
+func (r *Report) RError() *big.Float {
+	return r.diff(r.R, r.Pi)
+}
+
+func (r *Report) diff(a *big.Float, b uint64) *big.Float {
+	return new(big.Float).SetPrec(a.Prec()).Sub(a, new(big.Float).SetUint64(b))
+}
@@ -0,0 +1,115 @@
+package analytic_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/analytic"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// near returns true if got and want agree to within 10^-digits relative.
+func near(t *testing.T, got *big.Float, want string, digits int) bool {
+	w, _, err := big.ParseFloat(want, 10, 256, big.ToNearestEven)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got == nil {
+		return false
+	}
+	d := new(big.Float).Sub(got, w)
+	d.Abs(d)
+	tol := big.NewFloat(1)
+	for i := 0; i < digits; i++ {
+		tol.Quo(tol, big.NewFloat(10))
+	}
+	a := new(big.Float).Abs(w)
+	if a.Sign() == 0 {
+		a.SetInt64(1)
+	}
+	return d.Cmp(tol.Mul(tol, a)) <= 0
+}
+
+func TestLog(t *testing.T) {
+	for _, tc := range []struct{ x, want string }{
+		{"2", "0.693147180559945309417232121458176568075500134360255254120680"},
+		{"10", "2.30258509299404568401799145468436420760110148862877297603333"},
+		{"0.001", "-6.90775527898213705205397436405309262280330446588631892809998"},
+	} {
+		x, _, _ := big.ParseFloat(tc.x, 10, 200, big.ToNearestEven)
+		if got := analytic.Log(x, 0); !near(t, got, tc.want, 55) {
+			t.Errorf("Log(%s) = %.60g", tc.x, got)
+		}
+	}
+	if analytic.Log(big.NewFloat(0), 64) != nil {
+		t.Error("Log(0) not nil")
+	}
+}
+
+func TestChebyshev(t *testing.T) {
+	s := sieve.New(1e6)
+	for _, tc := range []struct {
+		x          uint64
+		theta, psi string
+	}{
+		{100, "83.7283903990639229450269228498790999178434388823329495730690",
+			"94.0453112293573922460049312446069272413260973114528531092486"},
+		{1e6, "998484.175025634292133973037829695938256746009694207090991732",
+			"999586.597495632922033061533011304463596335777880264135230170"},
+	} {
+		if got := analytic.Theta(s, tc.x, 200); !near(t, got, tc.theta, 55) {
+			t.Errorf("θ(%d) = %.60g", tc.x, got)
+		}
+		if got := analytic.Psi(s, tc.x, 200); !near(t, got, tc.psi, 55) {
+			t.Errorf("ψ(%d) = %.60g", tc.x, got)
+		}
+	}
+	if analytic.Theta(s, 2e6, 64) != nil {
+		t.Error("Theta accepted x > Limit")
+	}
+}
+
+func TestLiR(t *testing.T) {
+	for _, tc := range []struct{ x, li string }{
+		{"2", "1.04516378011749278484458888919461313652261557815120"},
+		{"10", "6.16559950478729793752298175266957"},
+		// Soldner's constant, the root of li
+		{"1.451369234883381050283968485892027449493", "0"},
+	} {
+		x, _, _ := big.ParseFloat(tc.x, 10, 200, big.ToNearestEven)
+		got := analytic.Li(x, 0)
+		if tc.li == "0" {
+			if got == nil || new(big.Float).Abs(got).Cmp(big.NewFloat(1e-38)) > 0 {
+				t.Errorf("li(%s) = %.20g", tc.x, got)
+			}
+		} else if !near(t, got, tc.li, len(tc.li)-3) {
+			t.Errorf("li(%s) = %.50g", tc.x, got)
+		}
+	}
+	r := analytic.R(big.NewFloat(1e6), 128)
+	if !near(t, r, "78527.3994291277048588702921409", 30) {
+		t.Errorf("R(1e6) = %.35g", r)
+	}
+	if analytic.Li(big.NewFloat(1), 64) != nil || analytic.R(big.NewFloat(.5), 64) != nil {
+		t.Error("out of domain")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	r := analytic.Compare(sieve.New(1e6), 1e6, 64)
+	if r.Pi != 78498 {
+		t.Fatal(r.Pi)
+	}
+	if e, _ := r.LiError().Float64(); e < 129.5 || e > 129.6 {
+		t.Errorf("li(1e6) - π(1e6) = %g", e)
+	}
+	if e, _ := r.RError().Float64(); e < 29.39 || e > 29.40 {
+		t.Errorf("R(1e6) - π(1e6) = %g", e)
+	}
+	if e, _ := r.ThetaError().Float64(); e > -1515 || e < -1516 {
+		t.Errorf("θ(1e6) - 1e6 = %g", e)
+	}
+	if e, _ := r.PsiError().Float64(); e > -413 || e < -414 {
+		t.Errorf("ψ(1e6) - 1e6 = %g", e)
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package analytic
+
+import (
+	"math"
+	"math/big"
+)
+
+// Log returns the natural logarithm of x, computed to prec bits.
+// If prec is 0, the precision of x is used.
+//
+// Log returns nil if x <= 0.
+//
+// With x = m·2^e, 1/2 <= m < 1, ln x = ln m + e·ln 2.  The mantissa is
+// brought close to 1 by repeated square roots, then ln m is summed from the
+// series 2·atanh((m-1)/(m+1)).
+func Log(x *big.Float, prec uint) *big.Float {
+	if x.Sign() <= 0 {
+		return nil
+	}
+	if prec == 0 {
+		prec = x.Prec()
+	}
+	wp := prec + 32
+	var m big.Float
+	m.SetPrec(wp)
+	e := x.MantExp(&m)
+	r := logMant(&m, wp)
+	if e != 0 {
+		l2 := ln2(wp)
+		r.Add(r, l2.Mul(l2, new(big.Float).SetInt64(int64(e))))
+	}
+	return r.SetPrec(prec)
+}
+
+// logMant returns ln m for m near 1, with working precision wp.
+func logMant(m *big.Float, wp uint) *big.Float {
+	// k square roots reduce |m-1| to about 2^-k, so the series
+	// converges about 2k bits per term.
+	k := uint(math.Sqrt(float64(wp)))
+	y := new(big.Float).SetPrec(wp + k).Set(m)
+	for i := uint(0); i < k; i++ {
+		y.Sqrt(y)
+	}
+	r := atanhRatio(y, wp+k)
+	return r.SetMantExp(r, int(k)+1).SetPrec(wp)
+}
+
+// atanhRatio returns atanh((y-1)/(y+1)) = ln(y)/2.
+func atanhRatio(y *big.Float, wp uint) *big.Float {
+	one := new(big.Float).SetPrec(wp).SetInt64(1)
+	t := new(big.Float).SetPrec(wp).Sub(y, one)
+	d := new(big.Float).SetPrec(wp).Add(y, one)
+	t.Quo(t, d)
+	t2 := new(big.Float).SetPrec(wp).Mul(t, t)
+	sum := new(big.Float).SetPrec(wp).Set(t)
+	var term, q big.Float
+	term.SetPrec(wp)
+	q.SetPrec(wp)
+	for i := int64(3); ; i += 2 {
+		t.Mul(t, t2)
+		if t.Sign() == 0 || t.MantExp(nil)-sum.MantExp(nil) < -int(wp) {
+			return sum
+		}
+		sum.Add(sum, q.Quo(t, term.SetInt64(i)))
+	}
+}
+
+// ln2 returns ln 2 to wp bits, as 2·atanh(1/3).
+func ln2(wp uint) *big.Float {
+	y := new(big.Float).SetPrec(wp).SetInt64(2)
+	r := atanhRatio(y, wp)
+	return r.SetMantExp(r, 1)
+}
+
+// euler returns the Euler-Mascheroni constant γ to wp bits, by the
+// Brent-McMillan algorithm B1.  With n ≈ wp·ln 2 / 4, the error is about
+// e^-4n.
+func euler(wp uint) *big.Float {
+	wp += 32
+	n := int64(float64(wp)*math.Ln2/4) + 1
+	nf := new(big.Float).SetPrec(wp).SetInt64(n)
+	n2 := new(big.Float).SetPrec(wp).Mul(nf, nf)
+	a := Log(nf, wp)
+	a.Neg(a)
+	b := new(big.Float).SetPrec(wp).SetInt64(1)
+	u := new(big.Float).SetPrec(wp).Set(a)
+	v := new(big.Float).SetPrec(wp).SetInt64(1)
+	var kf, t big.Float
+	kf.SetPrec(wp)
+	t.SetPrec(wp)
+	for k := int64(1); k <= 4*n; k++ {
+		kf.SetInt64(k)
+		b.Mul(b, n2)
+		b.Quo(b, t.Mul(&kf, &kf))
+		a.Mul(a, n2)
+		a.Quo(a, &kf)
+		a.Add(a, b)
+		a.Quo(a, &kf)
+		u.Add(u, a)
+		v.Add(v, b)
+	}
+	return u.Quo(u, v).SetPrec(wp - 32)
+}
@@ -0,0 +1,87 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package analytic
+
+import (
+	"math"
+	"math/big"
+)
+
+// zeta computes ζ(s) for integer s >= 2 by Borwein's algorithm 2,
+//
+//	ζ(s) = -1/(d_n (1 - 2^(1-s))) Σ_{k=0}^{n-1} (-1)^k (d_k - d_n) / (k+1)^s
+//
+// where d_k = n Σ_{i=0}^{k} (n+i-1)! 4^i / ((n-i)! (2i)!).  The error is
+// about (3+√8)^-n.
+//
+// Successive values of s are cheap, as (k+1)^s is kept for each k.
+type zeta struct {
+	wp  uint
+	s   int64
+	c   []big.Float // (-1)^k (d_k - d_n)
+	pow []big.Float // (k+1)^s
+	dn  big.Float
+}
+
+func newZeta(wp uint) *zeta {
+	n := int64(float64(wp)*math.Ln2/math.Log(3+math.Sqrt(8))) + 2
+	d := make([]big.Int, n+1)
+	var sum, t, f big.Int
+	for i := int64(0); i <= n; i++ {
+		// n (n+i-1)! 4^i / ((n-i)! (2i)!)
+		if i == 0 {
+			t.SetInt64(1)
+		} else {
+			t.MulRange(n-i+1, n+i-1)
+			t.Mul(&t, big.NewInt(n))
+			t.Lsh(&t, uint(2*i))
+			t.Quo(&t, f.MulRange(1, 2*i))
+		}
+		sum.Add(&sum, &t)
+		d[i].Set(&sum)
+	}
+	z := &zeta{wp: wp, s: 0,
+		c:   make([]big.Float, n),
+		pow: make([]big.Float, n),
+	}
+	z.dn.SetPrec(wp).SetInt(&d[n])
+	for k := range z.c {
+		z.c[k].SetPrec(wp).SetInt(t.Sub(&d[k], &d[n]))
+		if k&1 == 1 {
+			z.c[k].Neg(&z.c[k])
+		}
+		z.pow[k].SetPrec(wp).SetInt64(1)
+	}
+	return z
+}
+
+// at returns ζ(s).  s must be at least 2 and at least as large as the
+// previous call.
+func (z *zeta) at(s int64) *big.Float {
+	r := new(big.Float).SetPrec(z.wp).SetInt64(1)
+	if s > int64(z.wp)+2 {
+		// ζ(s) - 1 < 2^-wp
+		return r
+	}
+	var kf big.Float
+	kf.SetPrec(z.wp)
+	for k := range z.pow {
+		kf.SetInt64(int64(k + 1))
+		for i := z.s; i < s; i++ {
+			z.pow[k].Mul(&z.pow[k], &kf)
+		}
+	}
+	z.s = s
+	var sum, t big.Float
+	sum.SetPrec(z.wp)
+	t.SetPrec(z.wp)
+	for k := range z.c {
+		sum.Add(&sum, t.Quo(&z.c[k], &z.pow[k]))
+	}
+	// 1 - 2^(1-s)
+	t.SetMantExp(r, int(1-s))
+	t.Sub(r, &t)
+	t.Mul(&t, &z.dn)
+	return r.Neg(sum.Quo(&sum, &t))
+}
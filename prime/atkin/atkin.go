@@ -0,0 +1,203 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package atkin implements the sieve of Atkin and Bernstein, segmented.
+//
+// The sieve is based on a characterization of primes by quadratic forms.
+// A squarefree n > 3 is prime if and only if the number of positive
+// solutions (x, y) of
+//
+//	4x² + y² = n,        for n mod 12 = 1 or 5,
+//	3x² + y² = n,        for n mod 12 = 7,
+//	3x² - y² = n, x > y, for n mod 12 = 11,
+//
+// is odd.  A segment of odd numbers is sieved by toggling a bit for each
+// solution, then clearing multiples of p² for primes p.  See A. O. L. Atkin
+// and D. J. Bernstein, "Prime sieves using binary quadratic forms", Math.
+// Comp. 73 (2004).
+//
+// Segments of the range are sieved one at a time, so memory is proportional
+// to the square root of the limit, for the sieving primes and one segment.
+// Finding the solutions within a segment takes time proportional to the
+// square root of the end of the segment, so segments are made long
+// compared to that.
+package atkin
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/xmath"
+)
+
+const (
+	// bounds on the number of integers in a segment
+	minSegment = 1 << 16
+	maxSegment = 1 << 27
+)
+
+// Sieve holds sieving primes for iterating over primes up to Lim.
+type Sieve struct {
+	Lim    uint64
+	primes []uint32 // primes from 5 to √Lim, for eliminating squares
+}
+
+// New is the Sieve constructor.  It finds sieving primes up to the square
+// root of n.  Primes are found when Iterate is called.
+//
+// n is limited to math.MaxInt64.
+func New(n uint64) *Sieve {
+	if n > math.MaxInt64 {
+		n = math.MaxInt64
+	}
+	s := &Sieve{Lim: n}
+	r := xmath.FloorSqrt64(n)
+	sieve.New(r).Iterate(5, r, func(p uint64) (terminate bool) {
+		s.primes = append(s.primes, uint32(p))
+		return
+	})
+	return s
+}
+
+// Limit satisfies prime.Generator.
+func (s *Sieve) Limit() uint64 {
+	return s.Lim
+}
+
+// csqrt returns ceil(√n).
+func csqrt(n uint64) uint64 {
+	r := xmath.FloorSqrt64(n)
+	if r*r < n {
+		r++
+	}
+	return r
+}
+
+// Iterate iterates over primes between min and max inclusive, and calls
+// the visitor function for each prime.
+//
+// Iterate returns false if max > Limit(), otherwise it returns true.
+func (s *Sieve) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > s.Lim {
+		return false
+	}
+	for _, p := range []uint64{2, 3} {
+		if p >= min && p <= max && visitor(p) {
+			return true
+		}
+	}
+	if min > max || max < 5 {
+		return true
+	}
+	// segments start on a multiple of 128, and hold odd numbers,
+	// one bit per number.
+	lo := min &^ 127
+	segLen := 16 * xmath.FloorSqrt64(max)
+	if segLen < minSegment {
+		segLen = minSegment
+	}
+	if segLen > maxSegment {
+		segLen = maxSegment
+	}
+	if r := max - lo + 1; r < segLen {
+		segLen = r
+	}
+	segLen = (segLen + 127) &^ 127
+	bm := make([]uint64, segLen/128)
+	for ; lo <= max; lo += segLen {
+		hi := lo + segLen
+		for i := range bm {
+			bm[i] = 0
+		}
+		toggle := func(n uint64) {
+			i := (n - lo) >> 1
+			bm[i>>6] ^= 1 << (i & 63)
+		}
+		// 4x² + y², y odd
+		for x := uint64(1); 4*x*x+1 < hi; x++ {
+			a := 4 * x * x
+			y := uint64(1)
+			if a < lo {
+				y = csqrt(lo-a) | 1
+			}
+			for n := a + y*y; n < hi; n = a + y*y {
+				if r := n % 12; r == 1 || r == 5 {
+					toggle(n)
+				}
+				y += 2
+			}
+		}
+		// 3x² + y², x odd, y even
+		for x := uint64(1); 3*x*x+4 < hi; x += 2 {
+			a := 3 * x * x
+			y := uint64(2)
+			if a < lo {
+				y = (csqrt(lo-a) + 1) &^ 1
+			}
+			for n := a + y*y; n < hi; n = a + y*y {
+				if n%12 == 7 {
+					toggle(n)
+				}
+				y += 2
+			}
+		}
+		// 3x² - y², x > y, x + y odd.  3x² - (x-1)² = 2x² + 2x - 1.
+		x := csqrt(lo / 3)
+		if x == 0 {
+			x = 1
+		}
+		for ; 2*x*x+2*x-1 < hi; x++ {
+			a := 3 * x * x
+			if a <= lo {
+				continue
+			}
+			// largest y with a - y² >= lo, then down while n < hi
+			y := xmath.FloorSqrt64(a - lo)
+			if y >= x {
+				y = x - 1
+			}
+			if (x+y)&1 == 0 {
+				y--
+			}
+			for ; int64(y) >= 1; y -= 2 {
+				n := a - y*y
+				if n >= hi {
+					break
+				}
+				if n%12 == 11 {
+					toggle(n)
+				}
+			}
+		}
+		// clear multiples of squares of primes
+		for _, p32 := range s.primes {
+			p := uint64(p32)
+			q := p * p
+			if q >= hi {
+				break
+			}
+			m := (lo + q - 1) / q * q
+			if m&1 == 0 {
+				m += q
+			}
+			for ; m < hi; m += 2 * q {
+				i := (m - lo) >> 1
+				bm[i>>6] &^= 1 << (i & 63)
+			}
+		}
+		for i, w := range bm {
+			for ; w != 0; w &= w - 1 {
+				n := lo + 2*(uint64(i)*64+uint64(bits.TrailingZeros64(w))) + 1
+				if n > max {
+					return true
+				}
+				if n >= min && visitor(n) {
+					return true
+				}
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package atkin_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/atkin"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/sprp"
+)
+
+// Segments start on multiples of 128 and hold at least minSegment = 2^16
+// numbers, or 16√max for max above 2^24.
+func TestSegments(t *testing.T) {
+	const n = 1 << 26
+	ref := sieve.New(n)
+	a := atkin.New(n)
+	for _, r := range [][2]uint64{{0, 0}, {0, 17}, {127, 129}, {128, 256},
+		{0, 1 << 17}, {65535, 65537}, {127, 65536 + 129},
+		{129, 2*65536 + 1}, {65536*5 - 1, 65536*5 + 1},
+		{1<<24 - 65536*3 - 1, 1 << 24},   // 16√max = minSegment
+		{1<<25 + 3, 1<<25 + 3 + 3*93184}, // 16√max > minSegment
+		{n - 3*131072 - 200, n}} {
+		want := prime.Primes(ref, r[0], r[1])
+		got := prime.Primes(a, r[0], r[1])
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Primes(%d, %d): %d primes, want %d",
+				r[0], r[1], len(got), len(want))
+		}
+	}
+	if a.Iterate(0, n+1, func(uint64) bool { return false }) {
+		t.Error("Iterate accepted max > Limit")
+	}
+}
+
+// A window high above any sieve that would fit in memory, where the
+// quadratic forms have large x and y.
+func TestWindow(t *testing.T) {
+	const min, max = 1e15, 1e15 + 2e5
+	m := sprp.New()
+	var want []uint64
+	for n := uint64(min) + 1; n <= max; n += 2 {
+		if m.Prime64(n) {
+			want = append(want, n)
+		}
+	}
+	got := prime.Primes(atkin.New(max), min, max)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%d primes, want %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,53 @@
+package prime_test
+
+import (
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/atkin"
+	"github.com/soniakeys/integer/prime/queue"
+	"github.com/soniakeys/integer/prime/segment"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/wheel"
+)
+
+// generators for the shared benchmark.  window is true if the generator
+// can iterate a short range far above what a bitmap of the whole range
+// would allow.
+var generators = []struct {
+	name   string
+	new    func(n uint64) prime.Generator
+	window bool
+}{
+	{"sieve", func(n uint64) prime.Generator { return sieve.New(n) }, false},
+	{"segment", func(n uint64) prime.Generator { return segment.New(n) }, false},
+	{"queue", func(uint64) prime.Generator { return queue.PQueue{} }, false},
+	{"wheel", func(n uint64) prime.Generator { return wheel.New(n) }, true},
+	{"atkin", func(n uint64) prime.Generator { return atkin.New(n) }, true},
+}
+
+// BenchmarkGenerators constructs each generator and iterates over primes
+// of a range.  Workloads are all primes up to n, and a window of 1e7 above
+// 1e14.
+func BenchmarkGenerators(b *testing.B) {
+	visit := func(uint64) (terminate bool) { return }
+	for _, w := range []struct {
+		name     string
+		min, max uint64
+	}{
+		{"1e6", 0, 1e6},
+		{"1e8", 0, 1e8},
+		{"window1e14", 1e14, 1e14 + 1e7},
+	} {
+		for _, g := range generators {
+			if w.min > 0 && !g.window {
+				continue
+			}
+			b.Run(w.name+"/"+g.name, func(b *testing.B) {
+				for i := 0; i < b.N; i++ {
+					g.new(w.max).Iterate(w.min, w.max, visit)
+				}
+			})
+		}
+	}
+}
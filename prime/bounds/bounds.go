@@ -0,0 +1,177 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package bounds gives proven upper and lower bounds for the prime counting
+// function π(x) and the n-th prime p_n.
+//
+// Bounds are from Rosser and Schoenfeld (1962), Montgomery and Vaughan
+// (1973), Dusart (1999, 2010, 2018), and Axler (2019), with exact values
+// for small arguments.  Each bound is a theorem about the exact real value
+// of a formula x·g, where g is an expression in ln x and ln ln x.  g is
+// evaluated in checked fixed-point integer arithmetic, as an interval whose
+// endpoints are rounded outward at every step, logarithms included, so the
+// interval always contains the exact value.  The product x·g is then formed
+// exactly and an upper bound is rounded up, a lower bound down.  Results
+// saturate rather than overflow.  An upper bound is therefore never less
+// than the true value and a lower bound never greater.
+//
+// With 48 fractional bits, each rounding is less than 2^-48 and the
+// intervals for g are narrow, about 10^-12 relative, so results are looser
+// than the exact formulas by only a negligible fraction.
+//
+// The bounds are intended for sizing allocations, such as a sieve that
+// must hold at least n primes or a slice that must hold all primes of a
+// range.
+package bounds
+
+// small primes, p_1 through p_25.
+var small = []uint64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37, 41, 43, 47,
+	53, 59, 61, 67, 71, 73, 79, 83, 89, 97}
+
+// smallLimit is the bound for exact values from small.
+const smallLimit = 100
+
+// smallPi returns π(x) for x < smallLimit.
+func smallPi(x uint64) uint64 {
+	n := uint64(0)
+	for _, p := range small {
+		if p > x {
+			break
+		}
+		n++
+	}
+	return n
+}
+
+// PiUpper returns an upper bound for π(x), the number of primes <= x.
+//
+// The bound is x/ln x · (1 + 1/ln x + c/ln²x).  For x >= 355991, c = 2.51,
+// by Dusart 2010.  Otherwise c = 2.53816, by Dusart 2018, valid for x > 1.
+func PiUpper(x uint64) uint64 {
+	if x < smallLimit {
+		return smallPi(x)
+	}
+	c := ratio(253816, 100000)
+	if x >= 355991 {
+		c = ratio(251, 100)
+	}
+	l := logInt(x)
+	one := fix(1)
+	return one.div(l).mul(one.add(one.div(l)).add(c.div(l.mul(l)))).mulCeil(x)
+}
+
+// PiLower returns a lower bound for π(x), the number of primes <= x.
+//
+// For x >= 88789, the bound is x/ln x · (1 + 1/ln x + 2/ln²x), by
+// Dusart 2018.  For x >= 599 it is x/ln x · (1 + 1/ln x), by Dusart 2010.
+// Otherwise it is x/ln x, by Rosser and Schoenfeld, valid for x >= 17.
+func PiLower(x uint64) uint64 {
+	if x < smallLimit {
+		return smallPi(x)
+	}
+	l := logInt(x)
+	one := fix(1)
+	switch {
+	case x >= 88789:
+		return one.div(l).mul(one.add(one.div(l)).add(fix(2).div(l.mul(l)))).mulFloor(x)
+	case x >= 599:
+		return one.div(l).mul(one.add(one.div(l))).mulFloor(x)
+	}
+	return one.div(l).mulFloor(x)
+}
+
+// PiRange returns an upper bound for the number of primes between min and
+// max inclusive.
+//
+// It is the lesser of PiUpper(max) - PiLower(min-1) and the
+// Brun-Titchmarsh bound 2y/ln y of Montgomery and Vaughan, for an
+// interval of length y.  The latter is better for short intervals.
+func PiRange(min, max uint64) uint64 {
+	if min < 2 {
+		min = 2
+	}
+	if min > max {
+		return 0
+	}
+	n := PiUpper(max) - PiLower(min-1)
+	if y := max - min + 1; y >= smallLimit {
+		if bt := fix(2).div(logInt(y)).mulCeil(y); bt < n {
+			n = bt
+		}
+	}
+	return n
+}
+
+// dusart2010 returns ln n + ln ln n - 1 + (ln ln n - c)/ln n,
+// where l = ln n and ll = ln ln n.
+func dusart2010(l, ll, c interval) interval {
+	return l.add(ll).sub(fix(1)).add(ll.sub(c).div(l))
+}
+
+// axler2019 returns ln n + ln ln n - 1 + (ln ln n - 2)/ln n
+// - ((ln ln n)² - 6·ln ln n + c)/(2·ln²n).
+func axler2019(l, ll, c interval) interval {
+	two := fix(2)
+	q := ll.mul(ll).sub(fix(6).mul(ll)).add(c).div(two.mul(l).mul(l))
+	return dusart2010(l, ll, two).sub(q)
+}
+
+// PnUpper returns an upper bound for p_n, the n-th prime, where p_1 = 2.
+// The result saturates at math.MaxUint64.
+//
+// For n >= 46254381, the bound is the lesser of the Dusart 2010 bound and
+// n·(ln n + ln ln n - 1 + (ln ln n - 2)/ln n
+// - ((ln ln n)² - 6·ln ln n + 10.667)/(2·ln²n)), by Axler 2019.
+// For n >= 688383, it is n·(ln n + ln ln n - 1 + (ln ln n - 2)/ln n),
+// by Dusart 2010.  For n >= 39017 it is n·(ln n + ln ln n - 0.9484), by
+// Dusart 1999.  Otherwise it is n·(ln n + ln ln n), by Rosser and
+// Schoenfeld, valid for n >= 6.
+//
+// PnUpper(0) returns 0.
+func PnUpper(n uint64) uint64 {
+	if n <= uint64(len(small)) {
+		if n == 0 {
+			return 0
+		}
+		return small[n-1]
+	}
+	l := logInt(n)
+	ll := l.log()
+	switch {
+	case n >= 688383:
+		u := dusart2010(l, ll, fix(2)).mulCeil(n)
+		if n >= 46254381 {
+			if a := axler2019(l, ll, ratio(10667, 1000)).mulCeil(n); a < u {
+				u = a
+			}
+		}
+		return u
+	case n >= 39017:
+		return l.add(ll).sub(ratio(9484, 10000)).mulCeil(n)
+	}
+	return l.add(ll).mulCeil(n)
+}
+
+// PnLower returns a lower bound for p_n, the n-th prime, where p_1 = 2.
+//
+// The bound is the greater of n·(ln n + ln ln n - 1 + (ln ln n - 2.1)/ln n),
+// by Dusart 2010, valid for n >= 3, and n·(ln n + ln ln n - 1
+// + (ln ln n - 2)/ln n - ((ln ln n)² - 6·ln ln n + 11.321)/(2·ln²n)),
+// by Axler 2019, valid for n >= 2.
+//
+// PnLower(0) returns 0.
+func PnLower(n uint64) uint64 {
+	if n <= uint64(len(small)) {
+		if n == 0 {
+			return 0
+		}
+		return small[n-1]
+	}
+	l := logInt(n)
+	ll := l.log()
+	d := dusart2010(l, ll, ratio(21, 10)).mulFloor(n)
+	if a := axler2019(l, ll, ratio(11321, 1000)).mulFloor(n); a > d {
+		d = a
+	}
+	return d
+}
@@ -0,0 +1,112 @@
+package bounds_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/bounds"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+func TestPi(t *testing.T) {
+	const lim = 2e6
+	s := sieve.New(lim)
+	var pi, x uint64
+	check := func(x, pi uint64) {
+		if u := bounds.PiUpper(x); u < pi {
+			t.Fatalf("PiUpper(%d) = %d < %d", x, u, pi)
+		}
+		if l := bounds.PiLower(x); l > pi {
+			t.Fatalf("PiLower(%d) = %d > %d", x, l, pi)
+		}
+	}
+	// the formulas increase with x, so between jumps of π an upper bound
+	// is least at a prime and a lower bound greatest just before the next
+	// prime, unless the formula changes within the gap.
+	thresholds := []uint64{599, 88789, 355991}
+	s.Iterate(2, lim, func(p uint64) (terminate bool) {
+		check(p-1, pi)
+		for ; len(thresholds) > 0 && thresholds[0] <= p; thresholds = thresholds[1:] {
+			check(thresholds[0]-1, pi)
+			if thresholds[0] < p {
+				check(thresholds[0], pi)
+			}
+		}
+		pi++
+		check(p, pi)
+		if u := bounds.PnUpper(pi); u < p {
+			t.Fatalf("PnUpper(%d) = %d < %d", pi, u, p)
+		}
+		if l := bounds.PnLower(pi); l > p {
+			t.Fatalf("PnLower(%d) = %d > %d", pi, l, p)
+		}
+		return
+	})
+	for ; x < 100; x++ {
+		// exact below 100
+		if u, l := bounds.PiUpper(x), bounds.PiLower(x); u != l {
+			t.Fatalf("PiUpper(%d) = %d, PiLower = %d", x, u, l)
+		}
+	}
+	// not far too large
+	if u := bounds.PiUpper(lim); float64(u) > 1.01*float64(pi) {
+		t.Errorf("PiUpper(%d) = %d, π = %d", uint64(lim), u, pi)
+	}
+	if u := bounds.PnUpper(math.MaxUint64); u != math.MaxUint64 {
+		t.Error("PnUpper(MaxUint64) =", u)
+	}
+}
+
+// known values, π(10^k) and p_(10^k)
+var pi10 = []uint64{4, 25, 168, 1229, 9592, 78498, 664579, 5761455,
+	50847534, 455052511, 4118054813, 37607912018, 346065536839,
+	3204941750802, 29844570422669, 279238341033925, 2623557157654233,
+	24739954287740860, 234057667276344607}
+
+var p10 = []uint64{2, 29, 541, 7919, 104729, 1299709, 15485863, 179424673,
+	2038074743, 22801763489, 252097800623, 2760727302517, 29996224275833}
+
+func TestKnown(t *testing.T) {
+	x := uint64(1)
+	for _, pi := range pi10 {
+		x *= 10
+		u, l := bounds.PiUpper(x), bounds.PiLower(x)
+		if u < pi || l > pi {
+			t.Fatalf("π(%d) = %d, bounds %d, %d", x, pi, l, u)
+		}
+		// not far too large
+		if x >= 1e6 && (float64(u) > 1.01*float64(pi) ||
+			float64(l) < .99*float64(pi)) {
+			t.Errorf("π(%d) = %d, bounds %d, %d", x, pi, l, u)
+		}
+	}
+	n := uint64(1)
+	for _, p := range p10 {
+		u, l := bounds.PnUpper(n), bounds.PnLower(n)
+		if u < p || l > p {
+			t.Fatalf("p_%d = %d, bounds %d, %d", n, p, l, u)
+		}
+		if n >= 1e6 && (float64(u) > 1.001*float64(p) ||
+			float64(l) < .999*float64(p)) {
+			t.Errorf("p_%d = %d, bounds %d, %d", n, p, l, u)
+		}
+		n *= 10
+	}
+}
+
+func TestPiRange(t *testing.T) {
+	s := sieve.New(1e6)
+	for _, r := range [][2]uint64{{0, 1}, {0, 2}, {10, 10}, {11, 11},
+		{999000, 1e6}, {500000, 500100}, {1e5, 1e6}} {
+		var n uint64
+		s.Iterate(r[0], r[1], func(uint64) (terminate bool) {
+			n++
+			return
+		})
+		u := bounds.PiRange(r[0], r[1])
+		// the long range should be close.
+		if u < n || r[0] == 1e5 && float64(u) > 1.05*float64(n) {
+			t.Errorf("PiRange(%d, %d) = %d, count %d", r[0], r[1], u, n)
+		}
+	}
+}
@@ -0,0 +1,85 @@
+package bounds
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+// exact returns the real value of fixed-point v.
+func exact(v int64) *big.Rat {
+	return new(big.Rat).SetFrac(big.NewInt(v), new(big.Int).Lsh(big.NewInt(1), frac))
+}
+
+// holds checks that a holds want and is no more than width units wide.
+func holds(t *testing.T, name string, a interval, want *big.Rat, width int64) {
+	t.Helper()
+	if exact(a.lo).Cmp(want) > 0 || exact(a.hi).Cmp(want) < 0 {
+		t.Errorf("%s = [%s, %s], want %s", name,
+			exact(a.lo).FloatString(20), exact(a.hi).FloatString(20),
+			want.FloatString(20))
+	}
+	if a.hi-a.lo > width {
+		t.Errorf("%s = [%d, %d], more than %d units wide", name, a.lo, a.hi, width)
+	}
+}
+
+// Each product and quotient must be rounded outward, by less than a unit.
+func TestRound(t *testing.T) {
+	vals := []int64{1, 3, 1 << frac, 1<<frac + 1, 3<<frac/7 + 5,
+		100<<frac + 12345, 1<<(frac-10) - 1}
+	for _, a := range vals {
+		for _, b := range vals {
+			for _, sa := range []int64{1, -1} {
+				x, y := interval{sa * a, sa * a}, interval{b, b}
+				p := new(big.Rat).Mul(exact(x.lo), exact(b))
+				holds(t, "mul", x.mul(y), p, 1)
+				if b >= 1<<frac/64 { // quotient within range
+					q := new(big.Rat).Quo(exact(x.lo), exact(b))
+					holds(t, "div", x.div(y), q, 1)
+				}
+			}
+		}
+	}
+	holds(t, "ratio", ratio(253816, 100000), big.NewRat(253816, 100000), 1)
+	for _, f := range []func(){
+		func() { fix(1 << 15) },
+		func() { fix(1 << 14).mul(fix(2)) },
+		func() { fix(1 << 14).add(fix(1 << 14)) },
+		func() { fix(1).div(interval{1, 1}) },
+		func() { fix(1).div(fix(0)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Error("no panic")
+				}
+			}()
+			f()
+		}()
+	}
+}
+
+func TestLog(t *testing.T) {
+	for _, c := range []struct {
+		name string
+		l    interval
+		want string // to 40 digits
+	}{
+		{"ln 2", logInt(2), "0.6931471805599453094172321214581765680755"},
+		{"ln 3", logInt(3), "1.098612288668109691395245236922525704647"},
+		{"ln 10", logInt(10), "2.302585092994045684017991454684364207601"},
+		{"ln 1e6", logInt(1e6), "13.81551055796427410410794872810618524561"},
+		{"ln MaxUint64", logInt(math.MaxUint64),
+			"44.36141955583649980264864566469902513513"},
+		{"ln 1/8", ratio(1, 8).log(),
+			"-2.079441541679835928251696364374529704227"},
+		{"ln ln MaxUint64", logInt(math.MaxUint64).log(),
+			"3.792370162778007529489731560283916596986"},
+	} {
+		want, _ := new(big.Rat).SetString(c.want)
+		// ln 2 is about 50 units wide, and ln x = ln m + k·ln 2 with
+		// k < 64, or k < 112 for the log of a fixed-point value.
+		holds(t, c.name, c.l, want, 112*50)
+	}
+}
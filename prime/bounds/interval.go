@@ -0,0 +1,264 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package bounds
+
+import (
+	"math"
+	"math/bits"
+)
+
+// Fixed-point interval arithmetic.
+//
+// A real number v is represented by the integer v·2^frac in an int64, so
+// magnitudes up to 2^15 are representable.  Quantities here are logarithms
+// of uint64 values and small expressions in them, no more than about 2000
+// in magnitude.
+//
+// An interval [lo, hi] holds the exact real value of the expression it was
+// computed from.  Sums and differences are exact integer operations.
+// Products and quotients are computed exactly in 128 bits, then rounded
+// down for lo and up for hi.  Every operation is checked, panicking on
+// overflow rather than wrapping.
+//
+// Logarithms are summed from the series for atanh, with the truncated tail
+// bounded explicitly and included in the interval.
+
+// frac is the number of fractional bits.
+const frac = 48
+
+type interval struct{ lo, hi int64 }
+
+func overflow() { panic("bounds: fixed-point overflow") }
+
+// fix returns the interval holding n exactly.  n must be less than 2^15.
+func fix(n uint64) interval {
+	if n >= 1<<(63-frac) {
+		overflow()
+	}
+	v := int64(n) << frac
+	return interval{v, v}
+}
+
+// ratio returns an interval holding a/b.  a/b must be less than 2^15.
+func ratio(a, b uint64) interval {
+	hi, lo := a>>(64-frac), a<<frac
+	if hi >= b {
+		overflow()
+	}
+	q, r := bits.Div64(hi, lo, b)
+	if q > math.MaxInt64-1 {
+		overflow()
+	}
+	if r != 0 {
+		return interval{int64(q), int64(q) + 1}
+	}
+	return interval{int64(q), int64(q)}
+}
+
+// round returns the signed magnitude q, with remainder r, rounded up
+// toward +∞ if up is true and down toward -∞ otherwise.
+func round(q uint64, r bool, neg, up bool) int64 {
+	if r && up != neg {
+		q++
+	}
+	if q > math.MaxInt64 {
+		overflow()
+	}
+	if neg {
+		return -int64(q)
+	}
+	return int64(q)
+}
+
+func abs(a int64) (uint64, bool) {
+	if a < 0 {
+		return uint64(-a), true
+	}
+	return uint64(a), false
+}
+
+// mul returns a·b/2^frac, rounded up if up is true, else down.
+func mul(a, b int64, up bool) int64 {
+	ua, na := abs(a)
+	ub, nb := abs(b)
+	hi, lo := bits.Mul64(ua, ub)
+	if hi>>frac != 0 {
+		overflow()
+	}
+	q := hi<<(64-frac) | lo>>frac
+	return round(q, lo&(1<<frac-1) != 0, na != nb, up)
+}
+
+// quo returns a·2^frac/b for b > 0, rounded up if up is true, else down.
+func quo(a, b int64, up bool) int64 {
+	ua, neg := abs(a)
+	hi, lo := ua>>(64-frac), ua<<frac
+	if hi >= uint64(b) {
+		overflow()
+	}
+	q, r := bits.Div64(hi, lo, uint64(b))
+	return round(q, r != 0, neg, up)
+}
+
+func add(a, b int64) int64 {
+	s := a + b
+	if (a > 0 && b > 0 && s < 0) || (a < 0 && b < 0 && s >= 0) {
+		overflow()
+	}
+	return s
+}
+
+func (a interval) add(b interval) interval {
+	return interval{add(a.lo, b.lo), add(a.hi, b.hi)}
+}
+
+func (a interval) sub(b interval) interval {
+	if b.lo == math.MinInt64 {
+		overflow()
+	}
+	return interval{add(a.lo, -b.hi), add(a.hi, -b.lo)}
+}
+
+// mul returns a·b, the extremes of the products of endpoints.
+func (a interval) mul(b interval) interval {
+	r := interval{math.MaxInt64, math.MinInt64}
+	for _, x := range [2]int64{a.lo, a.hi} {
+		for _, y := range [2]int64{b.lo, b.hi} {
+			r.lo = min(r.lo, mul(x, y, false))
+			r.hi = max(r.hi, mul(x, y, true))
+		}
+	}
+	return r
+}
+
+// div returns a/b.  b must be positive.  The quotient increases with a
+// and is extreme at the endpoints of b.
+func (a interval) div(b interval) interval {
+	if b.lo <= 0 {
+		panic("bounds: division by non-positive interval")
+	}
+	return interval{
+		min(quo(a.lo, b.lo, false), quo(a.lo, b.hi, false)),
+		max(quo(a.hi, b.lo, true), quo(a.hi, b.hi, true)),
+	}
+}
+
+// ln2 holds ln 2 = 2·atanh(1/3).
+var ln2 = atanh2(ratio(1, 3), 20)
+
+// lnTab[j] holds ln(1 + j/64) = 2·atanh(j/(128+j)).
+var lnTab [64]interval
+
+func init() {
+	for j := range lnTab {
+		lnTab[j] = atanh2(ratio(uint64(j), uint64(128+j)), 20)
+	}
+}
+
+// logInt returns an interval holding ln x for x > 0.
+//
+// x = m·2^k with m in [1, 2).  m is truncated to frac bits, giving an
+// interval [m0, m1] holding m, and ln x = ln m + k·ln 2.
+func logInt(x uint64) interval {
+	if x == 0 {
+		panic("bounds: log of 0")
+	}
+	k := bits.Len64(x) - 1
+	var m0, m1 int64
+	if k >= frac {
+		s := uint(k - frac)
+		m0 = int64(x >> s)
+		m1 = m0
+		if x&(1<<s-1) != 0 {
+			m1++
+		}
+	} else {
+		m0 = int64(x << uint(frac-k))
+		m1 = m0
+	}
+	l := interval{logFrac(m0).lo, logFrac(m1).hi}
+	return l.add(fix(uint64(k)).mul(ln2))
+}
+
+// logFrac returns an interval holding ln m, for m in [1, 2] represented
+// with frac fractional bits.
+//
+// With c = 1 + j/64 the table point just below m,
+// ln m = ln c + 2·atanh((m-c)/(m+c)), where 0 <= (m-c)/(m+c) < 1/128.
+func logFrac(m int64) interval {
+	const step = 1 << (frac - 6) // 1/64
+	j := (m - 1<<frac) / step
+	if j == 64 { // m = 2
+		return ln2
+	}
+	c := 1<<frac + j*step
+	t := interval{m - c, m - c}.div(interval{m + c, m + c})
+	return lnTab[j].add(atanh2(t, 4))
+}
+
+// log returns ln a.  a must be positive.
+//
+// An endpoint v represents v/2^frac, so ln v/2^frac = ln v - frac·ln 2.
+func (a interval) log() interval {
+	if a.lo <= 0 {
+		panic("bounds: log of non-positive interval")
+	}
+	s := fix(frac).mul(ln2)
+	return interval{
+		logInt(uint64(a.lo)).sub(s).lo,
+		logInt(uint64(a.hi)).sub(s).hi,
+	}
+}
+
+// atanh2 returns 2·atanh(t) = 2·(t + t³/3 + t⁵/5 + ...), summing n terms.
+// t must be in [0, 1).
+//
+// The tail after n terms is less than t^(2n+1) / ((2n+1)·(1-t²)).  This
+// bound, evaluated at t.hi, is added to hi.
+func atanh2(t interval, n uint64) interval {
+	if t.lo < 0 {
+		panic("bounds: atanh2 of negative interval")
+	}
+	t2 := t.mul(t)
+	term := t
+	s := fix(0)
+	for i := uint64(0); i < n; i++ {
+		s = s.add(term.div(fix(2*i + 1)))
+		term = term.mul(t2)
+	}
+	a := interval{t.hi, t.hi}
+	tail := interval{term.hi, term.hi}.div(fix(2*n + 1)).div(fix(1).sub(a.mul(a)))
+	s.hi = add(s.hi, tail.hi)
+	return s.add(s)
+}
+
+// mulCeil returns the least integer >= x·a.hi, saturating at
+// math.MaxUint64, or 0 if a.hi <= 0.
+func (a interval) mulCeil(x uint64) uint64 {
+	if a.hi <= 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(x, uint64(a.hi))
+	if hi>>frac != 0 {
+		return math.MaxUint64
+	}
+	q := hi<<(64-frac) | lo>>frac
+	if lo&(1<<frac-1) != 0 && q < math.MaxUint64 {
+		q++
+	}
+	return q
+}
+
+// mulFloor returns the greatest integer <= x·a.lo, clamped to the uint64
+// range.
+func (a interval) mulFloor(x uint64) uint64 {
+	if a.lo <= 0 {
+		return 0
+	}
+	hi, lo := bits.Mul64(x, uint64(a.lo))
+	if hi>>frac != 0 {
+		return math.MaxUint64
+	}
+	return hi<<(64-frac) | lo>>frac
+}
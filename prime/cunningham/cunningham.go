@@ -0,0 +1,341 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package cunningham finds Sophie Germain primes and Cunningham chains.
+//
+// A Cunningham chain of the first kind is a sequence of primes p, 2p+1,
+// 4p+3, ..., each one twice the previous plus one.  A chain of the second
+// kind uses twice the previous minus one.  A Sophie Germain prime p is one
+// where p and 2p+1 are both prime, that is, where p starts a chain of the
+// first kind of length at least 2.
+//
+// Chains with origins in uint64 ranges are found with primes from any
+// prime.Generator, and members tested with a deterministic SPRP test.
+// Chains with big starting points are found with a multi-sieve, which
+// crosses off candidate origins where any member of the chain has a small
+// factor, followed by big.Int.ProbablyPrime.
+package cunningham
+
+import (
+	"math"
+	"math/big"
+	"runtime"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/sprp"
+)
+
+// Kind is the kind of a Cunningham chain.
+type Kind int
+
+// Chain kinds.
+const (
+	First  Kind = 1 // members 2p+1
+	Second Kind = 2 // members 2p-1
+)
+
+func (k Kind) String() string {
+	switch k {
+	case First:
+		return "first kind"
+	case Second:
+		return "second kind"
+	}
+	return "invalid kind"
+}
+
+// Chain is a complete Cunningham chain.  The member before Origin, if
+// any, is not prime and the member after the last is not prime.
+type Chain struct {
+	Kind   Kind
+	Origin *big.Int
+	Length int
+}
+
+// Members returns the primes of the chain, starting with Origin.
+func (c Chain) Members() []*big.Int {
+	m := make([]*big.Int, c.Length)
+	p := c.Origin
+	for i := range m {
+		m[i] = p
+		p = c.Kind.next(new(big.Int), p)
+	}
+	return m
+}
+
+// next computes the member after p, leaving the result in z.
+func (k Kind) next(z, p *big.Int) *big.Int {
+	z.Lsh(p, 1)
+	if k == First {
+		return z.Add(z, one)
+	}
+	return z.Sub(z, one)
+}
+
+// prev computes the member before p, leaving the result in z.
+// It returns nil if there is no such integer.
+func (k Kind) prev(z, p *big.Int) *big.Int {
+	if p.Bit(0) == 0 {
+		return nil
+	}
+	if k == First {
+		z.Sub(p, one)
+	} else {
+		z.Add(p, one)
+	}
+	return z.Rsh(z, 1)
+}
+
+var one = big.NewInt(1)
+
+// next64 returns the member after p and true, or false on overflow.
+func (k Kind) next64(p uint64) (uint64, bool) {
+	if p > (math.MaxUint64-1)/2 {
+		return 0, false
+	}
+	if k == First {
+		return 2*p + 1, true
+	}
+	return 2*p - 1, true
+}
+
+// SophieGermain type holds an underlying generator.
+//
+// SophieGermain satisfies prime.Generator, generating Sophie Germain
+// primes, OEIS A005384.
+type SophieGermain struct {
+	Gen prime.Generator
+}
+
+// Limit returns the largest p such that 2p+1 can be generated by the
+// underlying generator.
+func (s SophieGermain) Limit() uint64 {
+	l := s.Gen.Limit()
+	if l == 0 {
+		return 0
+	}
+	return (l - 1) / 2
+}
+
+// blockSize is the number of integers handled at a time.
+const blockSize = 1 << 16
+
+// Iterate calls the visitor function for each Sophie Germain prime between
+// min and max inclusive.
+//
+// For each block of p, primes 2p+1 are marked in a bitmap indexed by p,
+// then primes p of the block are checked against the bitmap.
+//
+// Iterate returns false if max > Limit().
+func (s SophieGermain) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > s.Limit() {
+		return false
+	}
+	if min > max {
+		return true
+	}
+	var bm [blockSize / 64]uint64
+	for b0 := min; ; b0 += blockSize {
+		nb := max - b0 + 1
+		if nb > blockSize || nb == 0 {
+			nb = blockSize
+		}
+		for i := range bm {
+			bm[i] = 0
+		}
+		s.Gen.Iterate(2*b0+1, 2*(b0+nb-1)+1, func(q uint64) (terminate bool) {
+			j := (q-1)/2 - b0
+			bm[j>>6] |= 1 << (j & 63)
+			return
+		})
+		t := false
+		s.Gen.Iterate(b0, b0+nb-1, func(p uint64) (terminate bool) {
+			j := p - b0
+			t = bm[j>>6]&(1<<(j&63)) != 0 && visitor(p)
+			return t
+		})
+		if t || max-b0 < blockSize {
+			return true
+		}
+	}
+}
+
+// Chains calls the visitor function for each complete chain of the given
+// kind, with length at least minLen and origin between min and max
+// inclusive, in increasing order of origin.
+//
+// Origins are primes from g.  Other members are tested with SPRP.Prime64.
+// A chain is cut short where a member would overflow uint64.
+//
+// Chains returns false if max > g.Limit().
+func Chains(g prime.Generator, kind Kind, minLen int, min, max uint64, visitor func(Chain) (terminate bool)) bool {
+	if max > g.Limit() {
+		return false
+	}
+	m := sprp.New()
+	isPrime := func(n uint64) bool {
+		return n >= 2 && m.Prime64(n)
+	}
+	var t bool
+	g.Iterate(min, max, func(p uint64) (terminate bool) {
+		// complete at the start
+		if p&1 == 1 && isPrime(p/2+uint64(kind-1)) {
+			return
+		}
+		length := 1
+		for n := p; ; length++ {
+			var ok bool
+			if n, ok = kind.next64(n); !ok || !isPrime(n) {
+				break
+			}
+		}
+		if length >= minLen {
+			t = visitor(Chain{kind, new(big.Int).SetUint64(p), length})
+		}
+		return t
+	})
+	return true
+}
+
+// Search type holds a sieve of small primes used to sieve for chains with
+// big origins.
+type Search struct {
+	Sieve *sieve.Sieve
+	// Reps is passed to big.Int.ProbablyPrime.  The zero value
+	// runs only the Baillie-PSW test.
+	Reps int
+}
+
+// NewSearch is a constructor that sieves small primes up to b.
+// (If you have a sieve already, you can just assign the Sieve member of
+// a zero value Search object.)
+func NewSearch(b uint64) *Search {
+	return &Search{Sieve: sieve.New(b)}
+}
+
+// Survivors returns offsets j in [0, l] such that none of the first minLen
+// members of the chain of the given kind starting at n+j has a factor
+// among the sieving primes, other than itself.
+//
+// The i-th member of a chain starting at n is 2^i·(n+s) - s, where s is
+// 1 for the first kind and -1 for the second.  For each odd sieving prime
+// q, it is divisible by q when n ≡ s·2^-i - s (mod q), so each q crosses
+// off up to minLen residues.
+//
+// n must not be negative.
+func (s *Search) Survivors(n *big.Int, l uint64, kind Kind, minLen int) []uint64 {
+	bm := make([]uint64, l/64+1)
+	small := n.IsUint64() && n.Uint64() <= s.Sieve.Lim
+	n64 := n.Uint64()
+	sign := int64(1)
+	if kind == Second {
+		sign = -1
+	}
+	// 0 and 1 are not prime
+	for j := uint64(0); small && n64+j < 2 && j <= l; j++ {
+		bm[j>>6] |= 1 << (j & 63)
+	}
+	var r, qb big.Int
+	s.Sieve.Iterate(2, s.Sieve.Lim, func(q uint64) (terminate bool) {
+		nq := r.Mod(n, qb.SetUint64(q)).Uint64()
+		if q == 2 {
+			// members after the first are odd.  cross off even origins.
+			for j := nq; j <= l; j += 2 {
+				if !(small && n64+j == 2) {
+					bm[j>>6] |= 1 << (j & 63)
+				}
+			}
+			return
+		}
+		inv2 := (q + 1) / 2 // 2^-1 mod q
+		pow := uint64(1)    // 2^-i mod q
+		sq := uint64(1)
+		if sign < 0 {
+			sq = q - 1
+		}
+		for i := 0; i < minLen; i++ {
+			// residue of n crossed off, s·2^-i - s
+			res := (sq*pow%q + q - sq) % q
+			j := (res + q - nq) % q
+			for ; j <= l; j += q {
+				if small && n64+j <= s.Sieve.Lim && member64(n64+j, i, sign) == q {
+					continue
+				}
+				bm[j>>6] |= 1 << (j & 63)
+			}
+			pow = pow * inv2 % q
+		}
+		return
+	})
+	var sv []uint64
+	for j := uint64(0); j <= l; j++ {
+		if bm[j>>6]&(1<<(j&63)) == 0 {
+			sv = append(sv, j)
+		}
+	}
+	return sv
+}
+
+// member64 returns member i of the chain starting at small n, or 0 if it
+// is out of range of sieving primes.
+func member64(n uint64, i int, sign int64) uint64 {
+	for ; i > 0; i-- {
+		if n > math.MaxUint32 {
+			return 0
+		}
+		n = uint64(int64(2*n) + sign)
+	}
+	return n
+}
+
+// Iterate visits complete chains of the given kind, with length at least
+// minLen and origin in the interval [n, n+l], in increasing order of
+// origin.
+//
+// Survivors of the multi-sieve are tested in parallel.
+//
+// n must not be negative.
+func (s *Search) Iterate(n *big.Int, l uint64, kind Kind, minLen int, visitor func(Chain) (terminate bool)) {
+	if n.Sign() < 0 {
+		return
+	}
+	sv := s.Survivors(n, l, kind, minLen)
+	chains := make([]Chain, len(sv))
+
+	xCh := make(chan int)
+	doneCh := make(chan int)
+	nCpu := runtime.GOMAXPROCS(0)
+	for i := 0; i < nCpu; i++ {
+		// workers
+		go func() {
+			var j, pv big.Int
+			for x := range xCh {
+				p := new(big.Int).Add(n, j.SetUint64(sv[x]))
+				if kind.prev(&pv, p) != nil && pv.Cmp(one) > 0 &&
+					pv.ProbablyPrime(s.Reps) {
+					continue // not complete at the start
+				}
+				length := 0
+				for m := p; m.ProbablyPrime(s.Reps); length++ {
+					m = kind.next(new(big.Int), m)
+				}
+				chains[x] = Chain{kind, p, length}
+			}
+			doneCh <- 0
+		}()
+	}
+	for x := range sv {
+		xCh <- x
+	}
+	close(xCh)
+	for i := 0; i < nCpu; i++ {
+		<-doneCh
+	}
+
+	for _, c := range chains {
+		if c.Length >= minLen && c.Length > 0 && visitor(c) {
+			return
+		}
+	}
+}
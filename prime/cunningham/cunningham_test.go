@@ -0,0 +1,107 @@
+package cunningham_test
+
+import (
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/cunningham"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// OEIS A005384.
+var sophieGermain = []uint64{2, 3, 5, 11, 23, 29, 41, 53, 83, 89, 113, 131,
+	173, 179, 191, 233, 239, 251, 281, 293, 359, 419, 431, 443, 491, 509}
+
+func TestSophieGermain(t *testing.T) {
+	sg := cunningham.SophieGermain{sieve.New(1e6)}
+	var got []uint64
+	sg.Iterate(0, 509, func(p uint64) (terminate bool) {
+		got = append(got, p)
+		return
+	})
+	if fmt.Sprint(got) != fmt.Sprint(sophieGermain) {
+		t.Fatal(got)
+	}
+	// block boundaries, compared to Chains.
+	n := 0
+	sg.Iterate(0, 3e5, func(uint64) (terminate bool) {
+		n++
+		return
+	})
+	m := 0
+	cunningham.Chains(sg.Gen, cunningham.First, 2, 0, 3e5,
+		func(c cunningham.Chain) (terminate bool) {
+			ms := c.Members()
+			for _, p := range ms[:len(ms)-1] {
+				if p.Uint64() <= 3e5 {
+					m++
+				}
+			}
+			return
+		})
+	if n != m {
+		t.Errorf("%d Sophie Germain primes, %d from chains", n, m)
+	}
+	if sg.Iterate(0, 1e6, func(uint64) bool { return false }) {
+		t.Error("Iterate accepted max > Limit")
+	}
+}
+
+func TestChains(t *testing.T) {
+	s := sieve.New(1e5)
+	for _, tc := range []struct {
+		kind cunningham.Kind
+		want string
+	}{
+		{cunningham.First, "[2:5 89:6 53639:5 53849:5 61409:5 63419:6 66749:5]"},
+		{cunningham.Second, "[1531:5 6841:5 15391:5 16651:7 44371:5 57991:5 83431:5]"},
+	} {
+		var got []string
+		cunningham.Chains(s, tc.kind, 5, 0, 1e5,
+			func(c cunningham.Chain) (terminate bool) {
+				got = append(got, fmt.Sprintf("%v:%d", c.Origin, c.Length))
+				return
+			})
+		if g := fmt.Sprint(got); g != tc.want {
+			t.Errorf("%v: got %s, want %s", tc.kind, g, tc.want)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	// small origins agree with Chains.
+	s := cunningham.NewSearch(1e3)
+	for _, kind := range []cunningham.Kind{cunningham.First, cunningham.Second} {
+		var want, got []string
+		cunningham.Chains(sieve.New(1e5), kind, 3, 0, 1e5,
+			func(c cunningham.Chain) (terminate bool) {
+				want = append(want, fmt.Sprint(c))
+				return
+			})
+		s.Iterate(big.NewInt(0), 1e5, kind, 3,
+			func(c cunningham.Chain) (terminate bool) {
+				got = append(got, fmt.Sprint(c))
+				return
+			})
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			t.Errorf("%v: Search found %d, Chains %d", kind, len(got), len(want))
+		}
+	}
+	// big origins
+	n := new(big.Int).Lsh(big.NewInt(1), 64)
+	var got []*big.Int
+	s.Iterate(n, 1e5, cunningham.First, 3,
+		func(c cunningham.Chain) (terminate bool) {
+			for _, m := range c.Members() {
+				if !m.ProbablyPrime(0) {
+					t.Errorf("%v: %v not prime", c, m)
+				}
+			}
+			got = append(got, c.Origin)
+			return
+		})
+	if len(got) != 4 || got[0].String() != "18446744073709566509" {
+		t.Error(got)
+	}
+}
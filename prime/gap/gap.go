@@ -0,0 +1,188 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package gap analyzes gaps between consecutive primes.
+//
+// Stats accumulate maximal gaps, OEIS A005250 and A002386, the first
+// occurrence of each gap size, OEIS A000230, and a histogram of gap sizes.
+// Primes are taken from any prime.Generator.
+package gap
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+
+	"github.com/soniakeys/integer/prime"
+)
+
+// Gap represents a gap between consecutive primes P and P+Size.
+type Gap struct {
+	Size uint64
+	P    uint64
+}
+
+// Merit returns the merit of a gap, Size/ln(P).
+func (g Gap) Merit() float64 {
+	return float64(g.Size) / math.Log(float64(g.P))
+}
+
+// Stats holds gap statistics for the primes of some range.
+//
+// Only gaps with both primes in the range are counted.
+type Stats struct {
+	First, Last uint64            // first and last prime found, or 0.
+	Hist        map[uint64]uint64 // count of gaps by size.
+	FirstOcc    map[uint64]uint64 // first prime p starting a gap of size.
+	Records     []Gap             // maximal gaps, in increasing order.
+}
+
+// NewStats constructs an empty Stats object.
+func NewStats() *Stats {
+	return &Stats{
+		Hist:     map[uint64]uint64{},
+		FirstOcc: map[uint64]uint64{},
+	}
+}
+
+// Add adds a prime to the statistics.
+//
+// Primes must be added in increasing order.
+func (s *Stats) Add(p uint64) {
+	if s.Last == 0 {
+		s.First = p
+	} else {
+		s.addGap(Gap{p - s.Last, s.Last})
+	}
+	s.Last = p
+}
+
+func (s *Stats) addGap(g Gap) {
+	s.Hist[g.Size]++
+	if _, ok := s.FirstOcc[g.Size]; !ok {
+		s.FirstOcc[g.Size] = g.P
+	}
+	if len(s.Records) == 0 || g.Size > s.Records[len(s.Records)-1].Size {
+		s.Records = append(s.Records, g)
+	}
+}
+
+// Merge merges statistics of t into s.
+//
+// The primes of t must all be greater than those of s.  The gap between
+// the last prime of s and the first prime of t is counted, so that the
+// result is as if all primes had been added to s.
+func (s *Stats) Merge(t *Stats) {
+	switch {
+	case t.First == 0:
+		return
+	case s.First == 0:
+		s.First = t.First
+	default:
+		s.addGap(Gap{t.First - s.Last, s.Last})
+	}
+	s.Last = t.Last
+	for size, n := range t.Hist {
+		s.Hist[size] += n
+		if _, ok := s.FirstOcc[size]; !ok {
+			s.FirstOcc[size] = t.FirstOcc[size]
+		}
+	}
+	// records of s+t are records of t that beat all records of s.
+	for _, g := range t.Records {
+		if len(s.Records) == 0 || g.Size > s.Records[len(s.Records)-1].Size {
+			s.Records = append(s.Records, g)
+		}
+	}
+}
+
+// Analyze computes gap statistics for primes of g between min and max,
+// inclusive.
+//
+// Analyze returns nil if max > g.Limit().
+func Analyze(g prime.Generator, min, max uint64) *Stats {
+	s := NewStats()
+	if !g.Iterate(min, max, func(p uint64) (terminate bool) {
+		s.Add(p)
+		return
+	}) {
+		return nil
+	}
+	return s
+}
+
+// segmentSize is the smallest range that AnalyzeParallel hands to a worker.
+const segmentSize = 1 << 20
+
+// AnalyzeParallel is like Analyze but iterates over segments of the range
+// in parallel, then merges the segment statistics.
+//
+// Iterate of g must be safe for concurrent use.  This is true of read-only
+// generators such as sieve.Sieve, segment.Sieve, and queue.PQueue
+// but not of sprp.SPRP.
+func AnalyzeParallel(g prime.Generator, min, max uint64) *Stats {
+	if max > g.Limit() {
+		return nil
+	}
+	if min > max {
+		return NewStats()
+	}
+	nCpu := runtime.GOMAXPROCS(0)
+	segments := uint64(nCpu) * 4
+	size := (max-min)/segments + 1
+	if size < segmentSize {
+		size = segmentSize
+	}
+	segments = (max-min)/size + 1
+
+	stats := make([]*Stats, segments)
+	segCh := make(chan uint64)
+	doneCh := make(chan int)
+
+	// dispatcher
+	go func() {
+		// start the workers
+		for i := 0; i < nCpu; i++ {
+			// workers
+			go func() {
+				for x := range segCh {
+					lo := min + x*size
+					hi := max
+					if x < segments-1 {
+						hi = lo + size - 1
+					}
+					stats[x] = Analyze(g, lo, hi)
+					doneCh <- 0
+				}
+			}()
+		}
+
+		// dispatch
+		for x := uint64(0); x < segments; x++ {
+			segCh <- x
+		}
+		close(segCh)
+	}()
+
+	// count completions
+	for x := uint64(0); x < segments; x++ {
+		<-doneCh
+	}
+	s := stats[0]
+	for _, t := range stats[1:] {
+		s.Merge(t)
+	}
+	return s
+}
+
+// WriteRecords writes a table of maximal gaps, with merit.
+func (s *Stats) WriteRecords(w io.Writer) error {
+	for _, g := range s.Records {
+		if _, err := fmt.Fprintf(w, "%6d %20d %8.4f\n",
+			g.Size, g.P, g.Merit()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
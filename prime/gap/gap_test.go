@@ -0,0 +1,59 @@
+package gap_test
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/gap"
+	"github.com/soniakeys/integer/prime/segment"
+)
+
+// OEIS A005250 and A002386, through 1e7.
+var records = []gap.Gap{
+	{1, 2}, {2, 3}, {4, 7}, {6, 23}, {8, 89}, {14, 113}, {18, 523},
+	{20, 887}, {22, 1129}, {34, 1327}, {36, 9551}, {44, 15683},
+	{52, 19609}, {72, 31397}, {86, 155921}, {96, 360653}, {112, 370261},
+	{114, 492113}, {118, 1349533}, {132, 1357201}, {148, 2010733},
+	{154, 4652353},
+}
+
+// OEIS A000230, some first occurrences.
+var firstOcc = map[uint64]uint64{
+	2: 3, 4: 7, 6: 23, 8: 89, 10: 139, 12: 199, 14: 113, 16: 1831,
+}
+
+func TestAnalyze(t *testing.T) {
+	const limit = 1e7
+	s := segment.New(limit)
+	st := gap.Analyze(s, 0, limit)
+	if !reflect.DeepEqual(st.Records, records) {
+		t.Errorf("Records = %v", st.Records)
+	}
+	for size, p := range firstOcc {
+		if st.FirstOcc[size] != p {
+			t.Errorf("FirstOcc[%d] = %d, want %d", size, st.FirstOcc[size], p)
+		}
+	}
+	var n uint64
+	for _, c := range st.Hist {
+		n += c
+	}
+	if n != 664579-1 {
+		t.Errorf("histogram holds %d gaps, want %d", n, 664579-1)
+	}
+	pt := gap.AnalyzeParallel(s, 0, limit)
+	if !reflect.DeepEqual(st, pt) {
+		t.Error("AnalyzeParallel result differs from Analyze")
+	}
+	if gap.Analyze(s, 0, limit+1) != nil {
+		t.Error("Analyze accepted max > Limit()")
+	}
+	var b bytes.Buffer
+	if err := st.WriteRecords(&b); err != nil {
+		t.Fatal(err)
+	}
+	if l := bytes.Count(b.Bytes(), []byte{'\n'}); l != len(records) {
+		t.Errorf("WriteRecords wrote %d lines, want %d", l, len(records))
+	}
+}
@@ -0,0 +1,298 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package gen provides combinators over prime.Generator.
+//
+// Each function takes one or more generators and returns a new generator.
+// Limit of the result is no more than the limit of the generators it is
+// built on, and Iterate of the result returns false if max > Limit(), so
+// combinators can be nested and passed anywhere a prime.Generator is
+// accepted.
+//
+// Growing is an adapter in the other direction, a generator that rebuilds
+// the generator it wraps with a larger limit as needed.
+package gen
+
+import (
+	"iter"
+	"math"
+
+	"github.com/soniakeys/integer/prime"
+)
+
+// Filter returns a generator of numbers n from g for which pred(n) is true.
+func Filter(g prime.Generator, pred func(n uint64) bool) prime.Generator {
+	return filter{g, pred}
+}
+
+type filter struct {
+	g    prime.Generator
+	pred func(uint64) bool
+}
+
+func (f filter) Limit() uint64 { return f.g.Limit() }
+
+func (f filter) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	return f.g.Iterate(min, max, func(n uint64) bool {
+		return f.pred(n) && visitor(n)
+	})
+}
+
+// Window returns a generator of numbers from g between min and max
+// inclusive.
+//
+// Limit of the result is the lesser of max and g.Limit().
+func Window(g prime.Generator, min, max uint64) prime.Generator {
+	if l := g.Limit(); max > l {
+		max = l
+	}
+	return window{g, min, max}
+}
+
+type window struct {
+	g        prime.Generator
+	min, max uint64
+}
+
+func (w window) Limit() uint64 { return w.max }
+
+func (w window) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > w.max {
+		return false
+	}
+	if min < w.min {
+		min = w.min
+	}
+	return w.g.Iterate(min, max, visitor)
+}
+
+// TakeN returns a generator of the first n numbers from g.
+//
+// Iterate of the result counts numbers of g from 0, so iterating over a
+// range high above 0 costs as much as iterating from 0.
+func TakeN(g prime.Generator, n uint64) prime.Generator {
+	return take{g, n}
+}
+
+type take struct {
+	g prime.Generator
+	n uint64
+}
+
+func (t take) Limit() uint64 { return t.g.Limit() }
+
+func (t take) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	var i uint64
+	return t.g.Iterate(0, max, func(p uint64) bool {
+		if i++; i > t.n {
+			return true
+		}
+		return p >= min && visitor(p)
+	})
+}
+
+// Offset returns a generator of numbers from g, skipping the first k.
+//
+// Like TakeN, Iterate of the result counts numbers of g from 0.
+func Offset(g prime.Generator, k uint64) prime.Generator {
+	return offset{g, k}
+}
+
+type offset struct {
+	g prime.Generator
+	k uint64
+}
+
+func (o offset) Limit() uint64 { return o.g.Limit() }
+
+func (o offset) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	var i uint64
+	return o.g.Iterate(0, max, func(p uint64) bool {
+		if i++; i <= o.k {
+			return false
+		}
+		return p >= min && visitor(p)
+	})
+}
+
+// Transform returns a generator of f(n) for numbers n from g.
+//
+// f must be strictly increasing over all uint64 values, so that numbers of
+// the result are in increasing order and a range of the result maps back
+// to a range of g.  If f(g.Limit()) overflows, Limit of the result is the
+// largest f(n) that does not.
+func Transform(g prime.Generator, f func(n uint64) uint64) prime.Generator {
+	t := transform{g: g, f: f}
+	// largest n <= g.Limit() where f has not wrapped around
+	l := g.Limit()
+	if l > 0 && f(l) < f(0) {
+		l = t.last(0, l, func(n uint64) bool { return f(n) >= f(0) })
+	}
+	t.lim = f(l)
+	t.gLim = l
+	return t
+}
+
+type transform struct {
+	g         prime.Generator
+	f         func(uint64) uint64
+	lim, gLim uint64
+}
+
+func (t transform) Limit() uint64 { return t.lim }
+
+// last returns the largest n in [lo, hi] for which ok(n), where ok is true
+// at lo and monotone decreasing.
+func (t transform) last(lo, hi uint64, ok func(uint64) bool) uint64 {
+	for lo < hi {
+		mid := lo + (hi-lo)/2 + 1
+		if ok(mid) {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return lo
+}
+
+func (t transform) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > t.lim {
+		return false
+	}
+	if min > max || t.f(0) > max {
+		return true
+	}
+	// n range of g with f(n) in [min, max]
+	hi := t.last(0, t.gLim, func(n uint64) bool { return t.f(n) <= max })
+	lo := uint64(0)
+	if t.f(0) < min {
+		lo = t.last(0, hi, func(n uint64) bool { return t.f(n) < min }) + 1
+	}
+	if lo > hi {
+		return true
+	}
+	return t.g.Iterate(lo, hi, func(n uint64) bool {
+		return visitor(t.f(n))
+	})
+}
+
+// minLimit returns the least limit of gs.
+func minLimit(gs []prime.Generator) uint64 {
+	l := uint64(math.MaxUint64)
+	for _, g := range gs {
+		if gl := g.Limit(); gl < l {
+			l = gl
+		}
+	}
+	return l
+}
+
+// Merge returns a generator of numbers from any of gs, in increasing order
+// and without duplicates.
+//
+// Limit of the result is the least limit of gs.  Iterate of the result
+// iterates each of gs once over the range, pulling numbers from them with
+// iter.Pull, so generators that must start from 0, like queue.PQueue, cost
+// no more than when iterated alone.
+func Merge(gs ...prime.Generator) prime.Generator {
+	return merge(gs)
+}
+
+type merge []prime.Generator
+
+func (m merge) Limit() uint64 { return minLimit(m) }
+
+func (m merge) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > m.Limit() {
+		return false
+	}
+	if min > max {
+		return true
+	}
+	// the next number of each generator not yet exhausted
+	next := make([]func() (uint64, bool), 0, len(m))
+	head := make([]uint64, 0, len(m))
+	for _, g := range m {
+		nx, stop := iter.Pull(prime.All(g, min, max))
+		defer stop()
+		if n, ok := nx(); ok {
+			next = append(next, nx)
+			head = append(head, n)
+		}
+	}
+	for len(next) > 0 {
+		n := head[0]
+		for _, h := range head[1:] {
+			if h < n {
+				n = h
+			}
+		}
+		if visitor(n) {
+			return true
+		}
+		for i := 0; i < len(next); {
+			if head[i] == n {
+				h, ok := next[i]()
+				if !ok {
+					next = append(next[:i], next[i+1:]...)
+					head = append(head[:i], head[i+1:]...)
+					continue
+				}
+				head[i] = h
+			}
+			i++
+		}
+	}
+	return true
+}
+
+// Cross returns a generator that checks that a and b generate the same
+// numbers.  It generates the numbers of a, and calls mismatch for each
+// number generated by only one of a and b, with inA true if the number was
+// generated by a.  If mismatch returns true, iteration is terminated.
+//
+// Limit of the result is the lesser limit of a and b.  As with Merge, a and
+// b are each iterated once over the range.
+func Cross(a, b prime.Generator, mismatch func(n uint64, inA bool) (terminate bool)) prime.Generator {
+	return cross{a, b, mismatch}
+}
+
+type cross struct {
+	a, b     prime.Generator
+	mismatch func(uint64, bool) bool
+}
+
+func (c cross) Limit() uint64 {
+	return minLimit([]prime.Generator{c.a, c.b})
+}
+
+func (c cross) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > c.Limit() {
+		return false
+	}
+	if min > max {
+		return true
+	}
+	next, stop := iter.Pull(prime.All(c.b, min, max))
+	defer stop()
+	nb, ok := next()
+	t := false
+	c.a.Iterate(min, max, func(n uint64) bool {
+		for ; ok && nb < n; nb, ok = next() {
+			if t = c.mismatch(nb, false); t {
+				return true
+			}
+		}
+		if ok && nb == n {
+			nb, ok = next()
+		} else if t = c.mismatch(n, true); t {
+			return true
+		}
+		t = visitor(n)
+		return t
+	})
+	for ; !t && ok; nb, ok = next() {
+		t = c.mismatch(nb, false)
+	}
+	return true
+}
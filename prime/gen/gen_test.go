@@ -0,0 +1,166 @@
+package gen_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/gen"
+	"github.com/soniakeys/integer/prime/queue"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/sprp"
+)
+
+func TestFilterWindow(t *testing.T) {
+	s := sieve.New(1000)
+	g := gen.Filter(s, func(p uint64) bool { return p%4 == 1 })
+	if got := fmt.Sprint(prime.Primes(g, 0, 60)); got != "[5 13 17 29 37 41 53]" {
+		t.Error("Filter:", got)
+	}
+	w := gen.Window(s, 100, 2000)
+	if w.Limit() != 1000 {
+		t.Error("Window Limit:", w.Limit())
+	}
+	if got := fmt.Sprint(prime.Primes(w, 0, 110)); got != "[101 103 107 109]" {
+		t.Error("Window:", got)
+	}
+	if prime.Primes(gen.Window(s, 0, 100), 0, 101) != nil {
+		t.Error("Window accepted max > Limit")
+	}
+}
+
+func TestTakeOffset(t *testing.T) {
+	s := sieve.New(1000)
+	if got := fmt.Sprint(prime.Primes(gen.TakeN(s, 5))); got != "[2 3 5 7 11]" {
+		t.Error("TakeN:", got)
+	}
+	if got := fmt.Sprint(prime.Primes(gen.TakeN(s, 5), 4, 10)); got != "[5 7]" {
+		t.Error("TakeN range:", got)
+	}
+	if got := fmt.Sprint(prime.Primes(gen.Offset(s, 3), 0, 20)); got != "[7 11 13 17 19]" {
+		t.Error("Offset:", got)
+	}
+	// 10th through 12th primes
+	g := gen.TakeN(gen.Offset(s, 9), 3)
+	if got := fmt.Sprint(prime.Primes(g, 0, 100)); got != "[29 31 37]" {
+		t.Error("TakeN(Offset):", got)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	s := sieve.New(100)
+	g := gen.Transform(s, func(p uint64) uint64 { return 2*p + 1 })
+	if g.Limit() != 201 {
+		t.Error("Limit:", g.Limit())
+	}
+	if got := fmt.Sprint(prime.Primes(g, 10, 30)); got != "[11 15 23 27]" {
+		t.Error("Transform:", got)
+	}
+	// safe primes 2p+1, as a filter of a transform
+	big := sieve.New(1000)
+	sg := gen.Filter(g, func(q uint64) bool {
+		return len(prime.Primes(big, q, q)) == 1
+	})
+	if got := fmt.Sprint(prime.Primes(sg, 0, 100)); got != "[5 7 11 23 47 59 83]" {
+		t.Error("Filter(Transform):", got)
+	}
+	// overflow
+	g = gen.Transform(sprp.New(), func(p uint64) uint64 { return p << 32 })
+	if g.Limit() != (1<<32-1)<<32 {
+		t.Errorf("Limit %x", g.Limit())
+	}
+	if got := fmt.Sprint(prime.Primes(g, 0, 3<<32)); got != fmt.Sprint([]uint64{2 << 32, 3 << 32}) {
+		t.Error("Transform:", got)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	s := sieve.New(2e5)
+	a := gen.Filter(s, func(p uint64) bool { return p%3 == 1 })
+	b := gen.Filter(s, func(p uint64) bool { return p%3 != 1 })
+	m := gen.Merge(a, b, s)
+	if m.Limit() != 2e5 {
+		t.Error("Limit:", m.Limit())
+	}
+	got := prime.Primes(m, 1000, 2e5)
+	want := prime.Primes(s, 1000, 2e5)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Merge: %d primes, want %d", len(got), len(want))
+	}
+}
+
+func TestCross(t *testing.T) {
+	s := sieve.New(2e5)
+	var bad []string
+	c := gen.Cross(s, sprp.New(), func(n uint64, inA bool) bool {
+		bad = append(bad, fmt.Sprint(n, inA))
+		return false
+	})
+	if c.Limit() != 2e5 {
+		t.Error("Limit:", c.Limit())
+	}
+	if n := len(prime.Primes(c, 0, 2e5)); n != 17984 || len(bad) > 0 {
+		t.Error(n, bad)
+	}
+	// a broken generator
+	broken := gen.Filter(
+		gen.Merge(s, gen.Transform(s, func(p uint64) uint64 { return p + 1 })),
+		func(n uint64) bool { return n != 65537 && (n <= 20 || n%2 == 1) })
+	c = gen.Cross(broken, s, func(n uint64, inA bool) bool {
+		bad = append(bad, fmt.Sprint(n, inA))
+		return false
+	})
+	prime.Primes(c, 0, 1e5)
+	if got := fmt.Sprint(bad); got != "[4 true 6 true 8 true 12 true 14 true 18 true 20 true 65537 false]" {
+		t.Error(got)
+	}
+}
+
+// calls counts calls to Iterate of a generator.
+type calls struct {
+	prime.Generator
+	n *int
+}
+
+func (c calls) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	*c.n++
+	return c.Generator.Iterate(min, max, visitor)
+}
+
+// Merge and Cross should iterate each source once over the range.
+// A PQueue starts from 0 on each call.
+func TestSinglePass(t *testing.T) {
+	const min, max = 5e5, 1e6
+	s := sieve.New(max)
+	want := fmt.Sprint(prime.Primes(s, min, max))
+	var nq, ns int
+	q := calls{queue.PQueue{}, &nq}
+	c := calls{s, &ns}
+	for _, g := range []prime.Generator{
+		gen.Merge(q, c),
+		gen.Cross(q, c, func(n uint64, inA bool) bool {
+			t.Error("mismatch", n, inA)
+			return true
+		}),
+	} {
+		nq, ns = 0, 0
+		var got []uint64
+		g.Iterate(min, max, func(p uint64) (terminate bool) {
+			got = append(got, p)
+			return
+		})
+		if fmt.Sprint(got) != want || nq != 1 || ns != 1 {
+			t.Errorf("%d primes, Iterate called %d, %d times", len(got), nq, ns)
+		}
+		// early termination
+		got = got[:0]
+		for p := range prime.All(g, min, max) {
+			if got = append(got, p); len(got) == 3 {
+				break
+			}
+		}
+		if fmt.Sprint(got) != "[500009 500029 500041]" {
+			t.Error("terminated:", got)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package gen
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// Growing is a generator that rebuilds an underlying generator with a
+// larger limit as larger values are requested.
+//
+// Growth is geometric, the limit at least doubling each time, so the total
+// cost of rebuilding is proportional to the cost of building the final
+// generator.  Growth is bounded by a memory ceiling.
+//
+// Growing is safe for concurrent use.
+type Growing struct {
+	// New constructs a generator with limit at least n.
+	New func(n uint64) prime.Generator
+	// Bytes estimates the memory used by a generator with limit n.
+	Bytes func(n uint64) uint64
+	// Ceiling is the most memory, as estimated by Bytes, allowed for the
+	// underlying generator.  0 means no ceiling.
+	Ceiling uint64
+
+	mu  sync.Mutex
+	g   prime.Generator
+	lim uint64 // largest limit within Ceiling
+}
+
+// minGrow is the smallest limit constructed.
+const minGrow = 1 << 16
+
+// NewGrowing constructs a Growing generator.
+func NewGrowing(newGen func(n uint64) prime.Generator, bytes func(n uint64) uint64, ceiling uint64) *Growing {
+	g := &Growing{New: newGen, Bytes: bytes, Ceiling: ceiling}
+	g.lim = math.MaxUint64
+	if ceiling > 0 && bytes(g.lim) > ceiling {
+		// binary search for the largest limit within the ceiling
+		lo, hi := uint64(0), g.lim
+		for lo < hi {
+			mid := lo + (hi-lo)/2 + 1
+			if bytes(mid) <= ceiling {
+				lo = mid
+			} else {
+				hi = mid - 1
+			}
+		}
+		g.lim = lo
+	}
+	return g
+}
+
+// NewGrowingSieve constructs a Growing generator over sieve.Sieve, with a
+// ceiling in bytes.  A ceiling of 0 means no ceiling.
+func NewGrowingSieve(ceiling uint64) *Growing {
+	return NewGrowing(func(n uint64) prime.Generator {
+		return sieve.New(n)
+	}, func(n uint64) uint64 {
+		// one bit for each 3 integers, in 4 byte words
+		return (n/96 + 1) * 4
+	}, ceiling)
+}
+
+// Limit returns the largest limit the underlying generator can grow to
+// within the memory ceiling.
+func (g *Growing) Limit() uint64 {
+	return g.lim
+}
+
+// Ensure grows the underlying generator if needed so that its limit is at
+// least max.
+//
+// Ensure returns an error if the generator would exceed the memory
+// ceiling.
+func (g *Growing) Ensure(max uint64) error {
+	_, err := g.ensure(max)
+	return err
+}
+
+func (g *Growing) ensure(max uint64) (prime.Generator, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.g != nil && max <= g.g.Limit() {
+		return g.g, nil
+	}
+	if max > g.lim {
+		return nil, fmt.Errorf("gen: limit %d needs %d bytes, more than "+
+			"ceiling of %d bytes", max, g.Bytes(max), g.Ceiling)
+	}
+	n := uint64(minGrow)
+	if g.g != nil && g.g.Limit() < math.MaxUint64/2 {
+		n = 2 * g.g.Limit()
+	}
+	if n < max {
+		n = max
+	}
+	if n > g.lim {
+		n = g.lim
+	}
+	g.g = g.New(n)
+	return g.g, nil
+}
+
+// Iterate satisfies prime.Generator, first growing the underlying
+// generator if needed.
+//
+// Iterate returns false if max would exceed the memory ceiling.  Ensure
+// can be called to get the error.
+func (g *Growing) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	ug, err := g.ensure(max)
+	if err != nil {
+		return false
+	}
+	return ug.Iterate(min, max, visitor)
+}
@@ -0,0 +1,49 @@
+package gen_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/gen"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+func TestGrowing(t *testing.T) {
+	var built []uint64
+	g := gen.NewGrowing(func(n uint64) prime.Generator {
+		built = append(built, n)
+		return sieve.New(n)
+	}, func(n uint64) uint64 { return n / 24 }, 41666)
+	if g.Limit() != 41666*24+23 {
+		t.Fatal("Limit:", g.Limit())
+	}
+	if n := len(prime.Primes(g, 0, 100)); n != 25 {
+		t.Error(n)
+	}
+	if n := len(prime.Primes(g, 0, 1e5)); n != 9592 {
+		t.Error(n)
+	}
+	// doubled to 131072 rather than grown to exactly 1e5
+	prime.Primes(g, 0, 131072)
+	if n := len(prime.Primes(g, 0, 1e6)); n != 78498 {
+		t.Error(n)
+	}
+	if len(built) != 3 || built[0] != 1<<16 || built[1] != 1<<17 ||
+		built[2] != 1e6 {
+		t.Error("built", built)
+	}
+	if prime.Primes(g, 0, 2e6) != nil {
+		t.Error("grew past ceiling")
+	}
+	if err := g.Ensure(2e6); err == nil || !strings.Contains(err.Error(), "ceiling") {
+		t.Error(err)
+	}
+}
+
+func TestGrowingSieve(t *testing.T) {
+	g := gen.NewGrowingSieve(0)
+	if n := len(prime.Primes(g, 0, 1e6)); n != 78498 {
+		t.Error(n)
+	}
+}
@@ -0,0 +1,333 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package goldbach counts and verifies Goldbach partitions, representations
+// of even numbers as sums of two primes.
+//
+// Counts are computed for all even numbers of a range at once by
+// convolution of the prime indicator, using number theoretic transforms
+// over blocks.  Verification follows the method of Oliveira e Silva,
+// finding only the minimal prime p such that 2n-p is prime.
+//
+// Both stream results, to a function or to an io.Writer, so that ranges
+// need not fit in memory.
+package goldbach
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/soniakeys/integer/prime"
+)
+
+// maxBlock is the largest block length, limited by the transform size.
+const maxBlock = 1 << 22
+
+// blockLen returns a power of 2 block length for ranges up to max,
+// dividing the range into about 16 blocks.
+func blockLen(max uint64) uint64 {
+	l := uint64(1 << 12)
+	for l < maxBlock && l*16 <= max {
+		l <<= 1
+	}
+	return l
+}
+
+// indicator sets a[j] = 1 for primes b0+j with j < l and b0+j <= max,
+// and sets all other elements of a to 0.
+func indicator(g prime.Generator, a []uint64, b0, l, max uint64) {
+	for i := range a {
+		a[i] = 0
+	}
+	if b0 > max {
+		return
+	}
+	end := b0 + l - 1
+	if end > max {
+		end = max
+	}
+	g.Iterate(b0, end, func(p uint64) (terminate bool) {
+		a[p-b0] = 1
+		return
+	})
+}
+
+// cacheBytes is the memory budget for cached block transforms.
+const cacheBytes = 1 << 30
+
+// transforms supplies forward transforms of blocks of the prime indicator,
+// mod each of the fields.  Transforms of the lowest blocks, which are used
+// for every later block of results, are cached up to a budget.  Others are
+// computed as needed into one of two scratch slots.
+type transforms struct {
+	g       prime.Generator
+	l, max  uint64
+	cache   [][2][]uint64
+	scratch [2][2][]uint64
+}
+
+func newTransforms(g prime.Generator, l, max, budget uint64) *transforms {
+	t := &transforms{g: g, l: l, max: max}
+	n := budget / (uint64(len(fields)) * 2 * l * 8)
+	if nb := max/l + 1; n > nb {
+		n = nb
+	}
+	t.cache = make([][2][]uint64, n)
+	for s := range t.scratch {
+		t.scratch[s] = newTransform(l)
+	}
+	return t
+}
+
+func newTransform(l uint64) [2][]uint64 {
+	return [2][]uint64{make([]uint64, 2*l), make([]uint64, 2*l)}
+}
+
+// get returns transforms of block b, using scratch slot if b is not cached.
+func (t *transforms) get(b uint64, slot int) [2][]uint64 {
+	if b < uint64(len(t.cache)) {
+		if t.cache[b][0] == nil {
+			t.cache[b] = newTransform(t.l)
+			t.compute(b, t.cache[b])
+		}
+		return t.cache[b]
+	}
+	t.compute(b, t.scratch[slot])
+	return t.scratch[slot]
+}
+
+func (t *transforms) compute(b uint64, dst [2][]uint64) {
+	a := dst[0]
+	indicator(t.g, a[:t.l], b*t.l, t.l, t.max)
+	for x := t.l; x < 2*t.l; x++ {
+		a[x] = 0
+	}
+	copy(dst[1], a)
+	for m, f := range fields {
+		f.ntt(dst[m], false)
+	}
+}
+
+// Counts calls f with R(2n) for each even 2n between min and max inclusive.
+// R(2n) is the number of unordered pairs of primes p <= q with p+q = 2n,
+// OEIS A045917.
+//
+// The prime indicator is split into blocks of length L, a power of 2 up to
+// 2^22.  The convolution for 2n in block K is the sum of block convolutions
+// A_i*A_j for i+j = K, plus the upper half of those for i+j = K-1.
+//
+// Cost:  Results for block K take K/2+1 pointwise products of transforms.
+// Transforms of the lowest blocks are cached, within 1GiB, and computed
+// once.  Higher blocks are transformed again for each pair that uses them.
+// For a short range near 2n = X, this is about X/L transforms of length 2L,
+// or about 2400 for X = 10^10.  For the whole range up to X it is about
+// (X/L)²/2 pointwise products, and up to (X/L)²/4 transforms for blocks
+// beyond the cache.  Memory is proportional to L plus the cache.
+//
+// Counts returns false if max > g.Limit() or if max >= maxCount, about
+// 4.7·10^17, where counts would no longer be exact.
+func Counts(g prime.Generator, min, max uint64, f func(n2, r uint64) (terminate bool)) bool {
+	return counts(g, min, max, blockLen(max), cacheBytes, f)
+}
+
+// counts is Counts with block length and cache budget as parameters.
+func counts(g prime.Generator, min, max, l, budget uint64, f func(n2, r uint64) bool) bool {
+	if max > g.Limit() || max >= maxCount {
+		return false
+	}
+	if min < 4 {
+		min = 4
+	}
+	if min > max {
+		return true
+	}
+	tr := newTransforms(g, l, max, budget)
+	s := newTransform(l)
+	carry := make([]uint64, l)
+	half := make([]uint64, l) // prime indicator for n, 2n in block K
+	k0, kMax := min/l, max/l
+	kStart := k0
+	if kStart > 0 {
+		kStart--
+	}
+	for k := kStart; k <= kMax; k++ {
+		for m := range s {
+			for x := range s[m] {
+				s[m][x] = 0
+			}
+		}
+		for i := uint64(0); 2*i <= k; i++ {
+			j := k - i
+			fi := tr.get(i, 0)
+			fj, mult := fi, uint64(1)
+			if i != j {
+				fj, mult = tr.get(j, 1), 2
+			}
+			for m, fd := range fields {
+				sm, fim, fjm := s[m], fi[m], fj[m]
+				for x := range sm {
+					sm[x] = (sm[x] + mult*fim[x]%fd.mod*fjm[x]) % fd.mod
+				}
+			}
+		}
+		for m, fd := range fields {
+			fd.ntt(s[m], true)
+		}
+		if k >= k0 {
+			// ordered counts c[t] for t = kl + x are s[x] + carry[x].
+			// n = t/2 lies in [kl/2, kl/2 + l/2).
+			indicator(g, half, k*l/2, l/2, max)
+			for x := uint64(0); x < l; x += 2 {
+				t := k*l + x
+				if t < min {
+					continue
+				}
+				if t > max {
+					return true
+				}
+				c := crt(s[0][x], s[1][x]) + carry[x] + half[x/2]
+				if f(t, c/2) {
+					return true
+				}
+			}
+		}
+		for x := uint64(0); x < l; x++ {
+			carry[x] = crt(s[0][l+x], s[1][l+x])
+		}
+	}
+	return true
+}
+
+// WriteCounts writes lines "2n R(2n)" for each even 2n between min and max
+// inclusive.  See Counts.
+//
+// WriteCounts returns an error if max > g.Limit(), if max >= maxCount as
+// for Counts, or if the writer fails.
+func WriteCounts(w io.Writer, g prime.Generator, min, max uint64) error {
+	if max > g.Limit() {
+		return fmt.Errorf("goldbach: max %d > generator limit %d",
+			max, g.Limit())
+	}
+	bw := bufio.NewWriter(w)
+	var err error
+	if !Counts(g, min, max, func(n2, r uint64) bool {
+		_, err = fmt.Fprintln(bw, n2, r)
+		return err != nil
+	}) {
+		return fmt.Errorf("goldbach: max %d >= %d", max, maxCount)
+	}
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// verifyPrimes is the bound on small primes tried first by Verify.
+// The minimal p is much smaller than this for all 2n < 4·10^18.
+const verifyPrimes = 1 << 14
+
+// Verify calls f with the minimal prime p such that 2n-p is prime, for
+// each even 2n between min and max inclusive.
+//
+// Even numbers are processed in blocks.  For each block, primes are found
+// in a window extending below the block by verifyPrimes, then for each 2n,
+// small primes p are tried in increasing order until 2n-p is found in the
+// window.  If 2n has no partition, f is called with p = 0.
+//
+// Verify returns false if max > g.Limit().
+func Verify(g prime.Generator, min, max uint64, f func(n2, p uint64) (terminate bool)) bool {
+	if max > g.Limit() {
+		return false
+	}
+	if min < 4 {
+		min = 4
+	}
+	min += min & 1
+	if min > max {
+		return true
+	}
+	spMax := uint64(verifyPrimes)
+	if spMax > g.Limit() {
+		spMax = g.Limit()
+	}
+	sp := prime.Primes(g, 2, spMax)
+	l := blockLen(max)
+	isPrime := make([]bool, l+verifyPrimes)
+	for b0 := min; b0 <= max; b0 += l {
+		end := b0 + l - 1
+		if end > max || end < b0 {
+			end = max
+		}
+		// window of primes [w0, end]
+		w0 := uint64(0)
+		if b0 > verifyPrimes {
+			w0 = b0 - verifyPrimes
+		}
+		for i := range isPrime {
+			isPrime[i] = false
+		}
+		g.Iterate(w0, end, func(p uint64) (terminate bool) {
+			isPrime[p-w0] = true
+			return
+		})
+		for n2 := b0; n2 <= end; n2 += 2 {
+			var r uint64
+			for _, p := range sp {
+				if p > n2/2 {
+					break
+				}
+				if isPrime[n2-p-w0] {
+					r = p
+					break
+				}
+			}
+			if r == 0 && n2/2 > verifyPrimes {
+				// outside the window.  continue with primes > verifyPrimes.
+				g.Iterate(verifyPrimes, n2/2, func(p uint64) bool {
+					g.Iterate(n2-p, n2-p, func(uint64) bool {
+						r = p
+						return true
+					})
+					return r > 0
+				})
+			}
+			if f(n2, r) {
+				return true
+			}
+			if n2 == max {
+				break
+			}
+		}
+		if max-b0 < l {
+			break
+		}
+	}
+	return true
+}
+
+// WriteVerify writes lines "2n p" with the minimal prime p such that 2n-p
+// is prime, for each even 2n between min and max inclusive.  See Verify.
+//
+// WriteVerify returns an error if max > g.Limit(), if the writer fails,
+// or if an even number with no partition is found.
+func WriteVerify(w io.Writer, g prime.Generator, min, max uint64) error {
+	if max > g.Limit() {
+		return fmt.Errorf("goldbach: max %d > generator limit %d",
+			max, g.Limit())
+	}
+	bw := bufio.NewWriter(w)
+	var err error
+	Verify(g, min, max, func(n2, p uint64) bool {
+		if p == 0 {
+			err = fmt.Errorf("goldbach: no partition for %d", n2)
+			return true
+		}
+		_, err = fmt.Fprintln(bw, n2, p)
+		return err != nil
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
@@ -0,0 +1,149 @@
+package goldbach_test
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/goldbach"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// OEIS A045917, starting with 2n = 4.
+var a045917 = []uint64{1, 1, 1, 2, 1, 2, 2, 2, 2, 3, 3, 3, 2, 3, 2, 4, 4,
+	2, 3, 4, 3, 4, 5, 4, 3, 5, 3, 4, 6, 3, 5, 6, 2, 5, 6, 5, 5, 7, 4, 5, 8}
+
+func TestCountsSmall(t *testing.T) {
+	s := sieve.New(1e3)
+	max := uint64(2 + 2*len(a045917))
+	n := 0
+	goldbach.Counts(s, 0, max, func(n2, r uint64) (terminate bool) {
+		if want := a045917[n2/2-2]; r != want {
+			t.Errorf("R(%d) = %d, want %d", n2, r, want)
+		}
+		n++
+		return
+	})
+	if n != len(a045917) {
+		t.Errorf("%d counts visited, want %d", n, len(a045917))
+	}
+}
+
+// brute returns ordered-pair-free counts R(2n) for 2n <= max.
+func brute(s *sieve.Sieve, max uint64) []uint64 {
+	isPrime := make([]bool, max+1)
+	ps := prime.Primes(s, 2, max)
+	for _, p := range ps {
+		isPrime[p] = true
+	}
+	r := make([]uint64, max+1)
+	for n2 := uint64(4); n2 <= max; n2 += 2 {
+		for _, p := range ps {
+			if p > n2/2 {
+				break
+			}
+			if isPrime[n2-p] {
+				r[n2]++
+			}
+		}
+	}
+	return r
+}
+
+func TestCountsBlocks(t *testing.T) {
+	// several blocks, and a range starting partway through.
+	s := sieve.New(1e5)
+	const max = 40000
+	want := brute(s, max)
+	for _, min := range []uint64{0, 29999} {
+		next := min + min%2
+		if next < 4 {
+			next = 4
+		}
+		goldbach.Counts(s, min, max, func(n2, r uint64) (terminate bool) {
+			if n2 != next {
+				t.Fatalf("min %d: visited %d, want %d", min, n2, next)
+			}
+			next += 2
+			if r != want[n2] {
+				t.Fatalf("R(%d) = %d, want %d", n2, r, want[n2])
+			}
+			return
+		})
+		if next != max+2 {
+			t.Errorf("min %d: stopped before %d", min, next)
+		}
+	}
+	if goldbach.Counts(s, 0, 2e5, nil) {
+		t.Error("Counts accepted max > Limit")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	s := sieve.New(1e6)
+	// records, OEIS A025018, A025019.
+	want := map[uint64]uint64{4: 2, 6: 3, 12: 5, 30: 7, 98: 19, 220: 23,
+		308: 31, 556: 47, 992: 73, 2642: 103, 5372: 139, 7426: 173,
+		43532: 211, 54244: 233, 63274: 293, 113672: 313, 128168: 331,
+		194428: 359, 194470: 383, 413572: 389, 503222: 523}
+	var rec uint64
+	n := 0
+	goldbach.Verify(s, 0, 1e6, func(n2, p uint64) (terminate bool) {
+		q := false
+		s.Iterate(n2-p, n2-p, func(uint64) bool {
+			q = true
+			return true
+		})
+		if p == 0 || !q {
+			t.Fatalf("%d: p = %d", n2, p)
+		}
+		if p <= rec {
+			return
+		}
+		rec = p
+		n++
+		if w := want[n2]; p != w {
+			t.Errorf("%d: record p = %d, want %d", n2, p, w)
+		}
+		return
+	})
+	if n != len(want) {
+		t.Errorf("%d checked, want %d", n, len(want))
+	}
+}
+
+func TestWrite(t *testing.T) {
+	s := sieve.New(1e3)
+	var b bytes.Buffer
+	if err := goldbach.WriteCounts(&b, s, 20, 24); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "20 2\n22 3\n24 3\n" {
+		t.Errorf("WriteCounts: %q", b.String())
+	}
+	b.Reset()
+	if err := goldbach.WriteVerify(&b, s, 97, 100); err != nil {
+		t.Fatal(err)
+	}
+	if b.String() != "98 19\n100 3\n" {
+		t.Errorf("WriteVerify: %q", b.String())
+	}
+	if goldbach.WriteVerify(&b, s, 0, 1e4) == nil {
+		t.Error("WriteVerify accepted max > Limit")
+	}
+}
+
+func ExampleCounts() {
+	goldbach.Counts(sieve.New(100), 90, 100, func(n2, r uint64) bool {
+		fmt.Println(n2, r)
+		return false
+	})
+	// Output:
+	// 90 9
+	// 92 4
+	// 94 5
+	// 96 7
+	// 98 3
+	// 100 6
+}
@@ -0,0 +1,99 @@
+package goldbach
+
+import (
+	"io"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/queue"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+func TestCRT(t *testing.T) {
+	// counts past the first modulus, as near 2n = 10^12
+	for _, c := range []uint64{0, 1, fields[0].mod - 1, fields[0].mod,
+		1.7e9, 123456789012345, maxCount - 1} {
+		if got := crt(c%fields[0].mod, c%fields[1].mod); got != c {
+			t.Errorf("crt of %d = %d", c, got)
+		}
+	}
+}
+
+// Counts are not exact from maxCount on, so WriteCounts must refuse.
+func TestWriteCountsMax(t *testing.T) {
+	if err := WriteCounts(io.Discard, queue.PQueue{}, maxCount, maxCount); err == nil {
+		t.Error("WriteCounts accepted max = maxCount")
+	}
+}
+
+// TestBlocks compares counts over many small blocks, with the transform
+// cache covering none, some, or all blocks, against counts from one block
+// covering the whole range.  Ranges spanning several blocks of maxBlock
+// would take minutes, but the block logic is the same for any length.
+func TestBlocks(t *testing.T) {
+	const max = 20000
+	s := sieve.New(max)
+	want := map[uint64]uint64{}
+	counts(s, 0, max, 1<<16, cacheBytes, func(n2, r uint64) bool {
+		want[n2] = r
+		return false
+	})
+	l := uint64(1 << 10)
+	perBlock := uint64(len(fields)) * 2 * l * 8
+	for _, budget := range []uint64{0, 7 * perBlock, cacheBytes} {
+		for _, min := range []uint64{0, 1023, 1024, 10001} {
+			n := 0
+			counts(s, min, max, l, budget, func(n2, r uint64) bool {
+				if r != want[n2] {
+					t.Fatalf("budget %d, min %d: R(%d) = %d, want %d",
+						budget, min, n2, r, want[n2])
+				}
+				n++
+				return false
+			})
+			lo := min + min%2
+			if lo < 4 {
+				lo = 4
+			}
+			if n != (max-int(lo))/2+1 {
+				t.Errorf("budget %d, min %d: %d counts", budget, min, n)
+			}
+		}
+	}
+}
+
+// TestMaxBlock counts across the boundary of blocks of length maxBlock,
+// checking against direct counts.
+func TestMaxBlock(t *testing.T) {
+	if testing.Short() {
+		t.Skip("transforms of length 2·maxBlock")
+	}
+	const min, max = maxBlock - 100, maxBlock + 100
+	s := sieve.New(max)
+	isPrime := make([]bool, max+1)
+	var ps []uint64
+	s.Iterate(2, max, func(p uint64) bool {
+		isPrime[p] = true
+		ps = append(ps, p)
+		return false
+	})
+	n := 0
+	counts(s, min, max, maxBlock, cacheBytes, func(n2, r uint64) bool {
+		var want uint64
+		for _, p := range ps {
+			if p > n2/2 {
+				break
+			}
+			if isPrime[n2-p] {
+				want++
+			}
+		}
+		if r != want {
+			t.Errorf("R(%d) = %d, want %d", n2, r, want)
+		}
+		n++
+		return false
+	})
+	if n != 101 {
+		t.Errorf("%d counts, want 101", n)
+	}
+}
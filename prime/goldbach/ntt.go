@@ -0,0 +1,90 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package goldbach
+
+// Number theoretic transforms mod primes with large power of 2 roots of
+// unity.  Convolutions here are of 0/1 prime indicators, so coefficients
+// are counts no larger than the length of the indicator.  Counts pass the
+// first modulus at about 2n = 5·10^11, so transforms are computed mod two
+// primes and counts are recovered with the Chinese remainder theorem.
+// This is exact for counts less than the product of the moduli, maxCount.
+
+// field is arithmetic mod a prime with a primitive root.
+type field struct {
+	mod, root uint64
+}
+
+var fields = [2]field{
+	{998244353, 3}, // 119·2^23 + 1
+	{469762049, 3}, // 7·2^26 + 1
+}
+
+// maxCount is the product of the moduli.  Counts less than maxCount are
+// recovered exactly.
+const maxCount = 998244353 * 469762049
+
+// m0Inv is the inverse of the first modulus mod the second.
+var m0Inv = fields[1].powMod(fields[0].mod, fields[1].mod-2)
+
+// crt returns c < maxCount from r0 = c mod fields[0].mod and
+// r1 = c mod fields[1].mod.
+func crt(r0, r1 uint64) uint64 {
+	m0, m1 := fields[0].mod, fields[1].mod
+	// c = r0 + m0·k where k = (r1 - r0)/m0 mod m1
+	k := (r1 + m1 - r0%m1) % m1 * m0Inv % m1
+	return r0 + m0*k
+}
+
+func (f field) powMod(a, e uint64) uint64 {
+	x := uint64(1)
+	for a %= f.mod; e > 0; e >>= 1 {
+		if e&1 != 0 {
+			x = x * a % f.mod
+		}
+		a = a * a % f.mod
+	}
+	return x
+}
+
+// ntt transforms a in place.  len(a) must be a power of 2, no more than
+// 2^23.  If inverse is true, the inverse transform is computed, including
+// scaling by 1/len(a).
+func (f field) ntt(a []uint64, inverse bool) {
+	n := len(a)
+	mod := f.mod
+	// bit reversal permutation
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j ^= bit
+		if i < j {
+			a[i], a[j] = a[j], a[i]
+		}
+	}
+	for length := 2; length <= n; length <<= 1 {
+		w := f.powMod(f.root, (mod-1)/uint64(length))
+		if inverse {
+			w = f.powMod(w, mod-2)
+		}
+		half := length / 2
+		for i := 0; i < n; i += length {
+			wk := uint64(1)
+			for k := 0; k < half; k++ {
+				u := a[i+k]
+				v := a[i+k+half] * wk % mod
+				a[i+k] = (u + v) % mod
+				a[i+k+half] = (u + mod - v) % mod
+				wk = wk * w % mod
+			}
+		}
+	}
+	if inverse {
+		nInv := f.powMod(uint64(n), mod-2)
+		for i := range a {
+			a[i] = a[i] * nInv % mod
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package prime
+
+import (
+	"context"
+	"iter"
+)
+
+// All returns an iterator over primes of pg between min and max inclusive,
+// for use with a range clause.
+//
+// No goroutine is involved.  Breaking out of the range loop terminates
+// iteration of pg.  The sequence is empty if max > pg.Limit().
+func All(pg Generator, min, max uint64) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		pg.Iterate(min, max, func(prime uint64) bool {
+			return !yield(prime)
+		})
+	}
+}
+
+// IteratorContext returns a channel of primes of pg between min and max
+// inclusive, like Iterator.
+//
+// The goroutine sending on the channel exits and closes the channel when
+// ctx is cancelled, so the consumer may stop reading early without leaking
+// it.  The channel is closed immediately if max > pg.Limit().
+func IteratorContext(ctx context.Context, pg Generator, min, max uint64) <-chan uint64 {
+	r := make(chan uint64)
+	go func() {
+		defer close(r)
+		pg.Iterate(min, max, func(prime uint64) bool {
+			select {
+			case r <- prime:
+				return false
+			case <-ctx.Done():
+				return true
+			}
+		})
+	}()
+	return r
+}
+
+// Batches returns a channel of slices of primes of pg between min and max
+// inclusive.  Each slice but the last holds size primes.  If size < 1, a
+// default of 1024 is used.
+//
+// Sending batches rather than single primes amortizes the cost of channel
+// operations.  The receiver owns each slice.  As with IteratorContext, the
+// sending goroutine exits when ctx is cancelled.
+func Batches(ctx context.Context, pg Generator, min, max uint64, size int) <-chan []uint64 {
+	if size < 1 {
+		size = 1024
+	}
+	r := make(chan []uint64)
+	go func() {
+		defer close(r)
+		send := func(b []uint64) bool {
+			select {
+			case r <- b:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+		b := make([]uint64, 0, size)
+		cancelled := false
+		pg.Iterate(min, max, func(prime uint64) bool {
+			if b = append(b, prime); len(b) < size {
+				return false
+			}
+			if !send(b) {
+				cancelled = true
+				return true
+			}
+			b = make([]uint64, 0, size)
+			return false
+		})
+		if !cancelled && len(b) > 0 {
+			send(b)
+		}
+	}()
+	return r
+}
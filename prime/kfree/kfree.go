@@ -0,0 +1,229 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package kfree computes k-free numbers, numbers not divisible by the k-th
+// power of any prime.
+//
+// Square-free numbers, OEIS A005117, are 2-free.  Cube-free numbers,
+// OEIS A004709, are 3-free.  A segmented sieve crosses off multiples of p^k
+// for primes p taken from a prime.Generator.
+package kfree
+
+import (
+	"math"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/xmath"
+)
+
+// Sieve type holds the parameter k and a generator of sieving primes.
+//
+// Sieve iterates over k-free numbers rather than primes, but otherwise
+// satisfies prime.Generator.
+type Sieve struct {
+	K   uint
+	Gen prime.Generator
+}
+
+// New constructs a Sieve for k-free numbers, with sieving primes from g.
+//
+// New returns nil for k < 2.
+func New(k uint, g prime.Generator) *Sieve {
+	if k < 2 {
+		return nil
+	}
+	return &Sieve{k, g}
+}
+
+// Limit returns the largest value that can be sieved with primes from
+// the underlying generator, (L+1)^k - 1 where L is the limit of the
+// generator.
+func (s *Sieve) Limit() uint64 {
+	l := s.Gen.Limit()
+	if l == math.MaxUint64 {
+		return l
+	}
+	b := l + 1
+	r := uint64(1)
+	for i := uint(0); i < s.K; i++ {
+		if r > math.MaxUint64/b {
+			return math.MaxUint64
+		}
+		r *= b
+	}
+	return r - 1
+}
+
+// root returns the largest b such that b^k <= n.
+func root(n uint64, k uint) uint64 {
+	b := uint64(math.Pow(float64(n), 1/float64(k)))
+	for b > 0 && !powLE(b, k, n) {
+		b--
+	}
+	for powLE(b+1, k, n) {
+		b++
+	}
+	return b
+}
+
+// powLE returns true if b^k <= n.
+func powLE(b uint64, k uint, n uint64) bool {
+	r := uint64(1)
+	for i := uint(0); i < k; i++ {
+		if b != 0 && r > n/b {
+			return false
+		}
+		r *= b
+	}
+	return r <= n
+}
+
+// pow returns p^k, which must not overflow.
+func pow(p uint64, k uint) uint64 {
+	r := uint64(1)
+	for i := uint(0); i < k; i++ {
+		r *= p
+	}
+	return r
+}
+
+// blockSize is the number of integers sieved at a time.
+const blockSize = 1 << 16
+
+// Iterate calls the visitor function for each k-free number between min
+// and max inclusive.
+//
+// Iterate returns false if max > Limit().
+func (s *Sieve) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	return s.blocks(min, max, func(b0 uint64, bm []uint64, nb uint64) bool {
+		for j := uint64(0); j < nb; j++ {
+			if bm[j>>6]&(1<<(j&63)) == 0 && visitor(b0+j) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// blocks sieves [min, max] in blocks, calling f with the start of each
+// block, a bitmap with bits set for numbers that are not k-free, and the
+// number of integers in the block.
+func (s *Sieve) blocks(min, max uint64, f func(b0 uint64, bm []uint64, nb uint64) bool) bool {
+	if max > s.Limit() {
+		return false
+	}
+	if min < 1 {
+		min = 1
+	}
+	if min > max {
+		return true
+	}
+	pks := []uint64{}
+	s.Gen.Iterate(2, root(max, s.K), func(p uint64) (terminate bool) {
+		pks = append(pks, pow(p, s.K))
+		return
+	})
+	var bm [blockSize / 64]uint64
+	for b0 := min; ; b0 += blockSize {
+		nb := max - b0 + 1
+		if nb > blockSize || nb == 0 {
+			nb = blockSize
+		}
+		for i := range bm {
+			bm[i] = 0
+		}
+		for _, pk := range pks {
+			for j := (pk - b0%pk) % pk; j < nb; j += pk {
+				bm[j>>6] |= 1 << (j & 63)
+			}
+		}
+		if f(b0, bm[:], nb) {
+			return true
+		}
+		if max-b0 < blockSize {
+			return true
+		}
+	}
+}
+
+// CountBlocks counts k-free numbers between min and max inclusive by
+// sieving, counting each block with popcount.
+//
+// The ok result is false if max > Limit().
+func (s *Sieve) CountBlocks(min, max uint64) (n uint64, ok bool) {
+	ok = s.blocks(min, max, func(b0 uint64, bm []uint64, nb uint64) bool {
+		full := nb / 64
+		for _, w := range bm[:full] {
+			n += 64 - uint64(xmath.BitCount64(w))
+		}
+		if r := nb % 64; r > 0 {
+			n += r - uint64(xmath.BitCount64(bm[full]&(1<<r-1)))
+		}
+		return false
+	})
+	return
+}
+
+// Count counts k-free numbers between min and max inclusive.
+//
+// It uses Q(x) = Σ μ(d)·⌊x/d^k⌋ over d <= x^(1/k), where μ is the
+// Möbius function, computed by sieving with primes from the underlying
+// generator.  This takes time and space proportional to x^(1/k) rather
+// than x.
+//
+// The ok result is false if max > Limit().
+func (s *Sieve) Count(min, max uint64) (n uint64, ok bool) {
+	if max > s.Limit() {
+		return 0, false
+	}
+	if min < 1 {
+		min = 1
+	}
+	if min > max {
+		return 0, true
+	}
+	return s.q(max) - s.q(min-1), true
+}
+
+// q returns the number of k-free numbers <= x.
+func (s *Sieve) q(x uint64) uint64 {
+	if x == 0 {
+		return 0
+	}
+	r := root(x, s.K)
+	mu := mobius(s.Gen, r)
+	// partial sums may be negative but the result is not, so
+	// unsigned wraparound gives the right answer.
+	var sum uint64
+	for d := uint64(1); d <= r; d++ {
+		switch mu[d] {
+		case 1:
+			sum += x / pow(d, s.K)
+		case -1:
+			sum -= x / pow(d, s.K)
+		}
+	}
+	return sum
+}
+
+// mobius returns μ(d) for d from 0 to n, using primes from g.
+// μ(0) is returned as 0.
+func mobius(g prime.Generator, n uint64) []int8 {
+	mu := make([]int8, n+1)
+	for i := range mu {
+		mu[i] = 1
+	}
+	mu[0] = 0
+	g.Iterate(2, n, func(p uint64) (terminate bool) {
+		for m := p; m <= n; m += p {
+			mu[m] = -mu[m]
+		}
+		if p <= n/p {
+			for m := p * p; m <= n; m += p * p {
+				mu[m] = 0
+			}
+		}
+		return
+	})
+	return mu
+}
@@ -0,0 +1,72 @@
+package kfree_test
+
+import (
+	"testing"
+
+	"github.com/soniakeys/integer/prime/kfree"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// OEIS A005117, A004709.
+var squareFree = []uint64{1, 2, 3, 5, 6, 7, 10, 11, 13, 14, 15, 17, 19, 21,
+	22, 23, 26, 29, 30, 31, 33, 34, 35, 37, 38, 39, 41, 42, 43, 46, 47}
+var cubeFree = []uint64{1, 2, 3, 4, 5, 6, 7, 9, 10, 11, 12, 13, 14, 15, 17,
+	18, 19, 20, 21, 22, 23, 25, 26, 28, 29, 30, 31, 33, 34, 35, 36}
+
+func TestIterate(t *testing.T) {
+	s := sieve.New(1e4)
+	for _, tc := range []struct {
+		k    uint
+		want []uint64
+	}{{2, squareFree}, {3, cubeFree}} {
+		var got []uint64
+		kfree.New(tc.k, s).Iterate(0, tc.want[len(tc.want)-1],
+			func(n uint64) (terminate bool) {
+				got = append(got, n)
+				return
+			})
+		if len(got) != len(tc.want) {
+			t.Errorf("%d-free: %v", tc.k, got)
+			continue
+		}
+		for i, n := range tc.want {
+			if got[i] != n {
+				t.Errorf("%d-free: %v", tc.k, got)
+				break
+			}
+		}
+	}
+	if kfree.New(1, s) != nil {
+		t.Error("New accepted k = 1")
+	}
+}
+
+// OEIS A071172, number of square-free numbers <= 10^n.
+func TestCount(t *testing.T) {
+	s := kfree.New(2, sieve.New(1e6))
+	for _, tc := range []struct{ x, n uint64 }{
+		{10, 7}, {100, 61}, {1000, 608}, {1e4, 6083}, {1e5, 60794},
+		{1e6, 607926}, {1e12, 607927102274},
+	} {
+		if n, ok := s.Count(0, tc.x); !ok || n != tc.n {
+			t.Errorf("Count(0, %d) = %d, want %d", tc.x, n, tc.n)
+		}
+		if tc.x > 1e6 {
+			continue
+		}
+		if n, ok := s.CountBlocks(0, tc.x); !ok || n != tc.n {
+			t.Errorf("CountBlocks(0, %d) = %d, want %d", tc.x, n, tc.n)
+		}
+	}
+	c := kfree.New(3, sieve.New(1e4))
+	for _, w := range []struct{ min, max uint64 }{
+		{1, 1e6}, {12345, 543210}, {999999, 1e6},
+	} {
+		n1, _ := c.Count(w.min, w.max)
+		n2, _ := c.CountBlocks(w.min, w.max)
+		if n1 != n2 {
+			t.Errorf("cube-free (%d, %d): Count %d, CountBlocks %d",
+				w.min, w.max, n1, n2)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package poly
+
+// pmod is a polynomial with coefficients mod some prime p < 2^32,
+// listed constant term first.
+type pmod []uint64
+
+// trim removes high order zero coefficients.
+func (f pmod) trim() pmod {
+	for len(f) > 0 && f[len(f)-1] == 0 {
+		f = f[:len(f)-1]
+	}
+	return f
+}
+
+func (f pmod) eval(x, p uint64) (v uint64) {
+	for i := len(f) - 1; i >= 0; i-- {
+		v = (v*x + f[i]) % p
+	}
+	return
+}
+
+func (f pmod) monic(p uint64) pmod {
+	inv := powMod(f[len(f)-1], p-2, p)
+	m := make(pmod, len(f))
+	for i, c := range f {
+		m[i] = c * inv % p
+	}
+	return m
+}
+
+func (f pmod) sub(g pmod, p uint64) pmod {
+	n := len(f)
+	if len(g) > n {
+		n = len(g)
+	}
+	d := make(pmod, n)
+	copy(d, f)
+	for i, c := range g {
+		d[i] = (d[i] + p - c) % p
+	}
+	return d.trim()
+}
+
+func (f pmod) mul(g pmod, p uint64) pmod {
+	if len(f) == 0 || len(g) == 0 {
+		return nil
+	}
+	m := make(pmod, len(f)+len(g)-1)
+	for i, a := range f {
+		for j, b := range g {
+			m[i+j] = (m[i+j] + a*b) % p
+		}
+	}
+	return m
+}
+
+// divMod returns quotient and remainder of f / m, for monic m.
+func (f pmod) divMod(m pmod, p uint64) (q, r pmod) {
+	r = append(pmod{}, f...)
+	dm := len(m) - 1
+	if len(r)-1 < dm {
+		return nil, r.trim()
+	}
+	q = make(pmod, len(r)-dm)
+	for i := len(r) - 1; i >= dm; i-- {
+		c := r[i]
+		if c == 0 {
+			continue
+		}
+		q[i-dm] = c
+		for j, mc := range m {
+			k := i - dm + j
+			r[k] = (r[k] + p - c*mc%p) % p
+		}
+	}
+	return q.trim(), r[:dm].trim()
+}
+
+// powMod returns f^e mod m, for monic m.
+func (f pmod) powMod(e uint64, m pmod, p uint64) pmod {
+	x := pmod{1}
+	_, b := f.divMod(m, p)
+	for ; e > 0; e >>= 1 {
+		if e&1 != 0 {
+			_, x = x.mul(b, p).divMod(m, p)
+		}
+		_, b = b.mul(b, p).divMod(m, p)
+	}
+	return x
+}
+
+// gcd returns the monic gcd of f and g.
+func gcd(f, g pmod, p uint64) pmod {
+	f, g = f.trim(), g.trim()
+	for len(g) > 0 {
+		g = g.monic(p)
+		_, r := f.divMod(g, p)
+		f, g = g, r
+	}
+	if len(f) == 0 {
+		return f
+	}
+	return f.monic(p)
+}
+
+// split appends the roots of f to r and returns the extended slice.
+// f must be monic, squarefree, and a product of linear factors, and p odd.
+func (f pmod) split(p uint64, r []uint64) []uint64 {
+	switch len(f) - 1 {
+	case 0:
+		return r
+	case 1:
+		return append(r, (p-f[0])%p)
+	}
+	for a := uint64(1); ; a++ {
+		h := pmod{a, 1}.powMod((p-1)/2, f, p).sub(pmod{1}, p)
+		d := gcd(f, h, p)
+		if k := len(d) - 1; k > 0 && k < len(f)-1 {
+			q, _ := f.divMod(d, p)
+			return q.split(p, d.split(p, r))
+		}
+	}
+}
+
+func powMod(b, e, p uint64) uint64 {
+	x := uint64(1)
+	for b %= p; e > 0; e >>= 1 {
+		if e&1 != 0 {
+			x = x * b % p
+		}
+		b = b * b % p
+	}
+	return x
+}
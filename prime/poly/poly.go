@@ -0,0 +1,279 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package poly finds primes of special forms f(n), such as n²+1 or k·2ⁿ+1.
+//
+// The sieve is over n rather than over integers.  For each small prime p,
+// the roots of f mod p are found once, then values of n congruent to a root
+// are crossed off.  Survivors are confirmed with big.Int.ProbablyPrime.
+//
+// Counts can be compared to the prediction of the Bateman-Horn conjecture.
+package poly
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/soniakeys/integer/prime"
+)
+
+// Form is a function of n whose prime values are sought.
+type Form interface {
+	// Roots returns the distinct residues r mod p such that f(r) ≡ 0 (mod p).
+	Roots(p uint64) []uint64
+	// Eval computes f(n), leaving the result in z.  It returns z.
+	Eval(z *big.Int, n uint64) *big.Int
+	// Float64 returns an approximation of f(n).
+	Float64(n uint64) float64
+}
+
+// Poly is an integer polynomial.
+// Coefficients are listed constant term first, so Poly{1, 0, 1} is n²+1.
+type Poly []int64
+
+// Eval satisfies Form.
+func (f Poly) Eval(z *big.Int, n uint64) *big.Int {
+	var nb, c big.Int
+	nb.SetUint64(n)
+	z.SetInt64(0)
+	for i := len(f) - 1; i >= 0; i-- {
+		z.Add(z.Mul(z, &nb), c.SetInt64(f[i]))
+	}
+	return z
+}
+
+// Float64 satisfies Form.
+func (f Poly) Float64(n uint64) float64 {
+	x := float64(n)
+	var v float64
+	for i := len(f) - 1; i >= 0; i-- {
+		v = v*x + float64(f[i])
+	}
+	return v
+}
+
+// bruteLimit is the largest p for which Roots evaluates f at every residue.
+const bruteLimit = 256
+
+// Roots satisfies Form.  p must be less than 2^32.
+//
+// For p > bruteLimit, roots are found by the Cantor-Zassenhaus method,
+// first taking gcd(f, x^p - x) to get the product of linear factors of f,
+// then splitting it with gcd((x+a)^((p-1)/2) - 1, g) for a = 1, 2, ...
+func (f Poly) Roots(p uint64) []uint64 {
+	// reduce coefficients mod p
+	g := make(pmod, len(f))
+	for i, c := range f {
+		m := c % int64(p)
+		if m < 0 {
+			m += int64(p)
+		}
+		g[i] = uint64(m)
+	}
+	g = g.trim()
+	if len(g) == 0 {
+		// f ≡ 0, every residue is a root
+		r := make([]uint64, p)
+		for i := range r {
+			r[i] = uint64(i)
+		}
+		return r
+	}
+	var r []uint64
+	if p <= bruteLimit {
+		for x := uint64(0); x < p; x++ {
+			if g.eval(x, p) == 0 {
+				r = append(r, x)
+			}
+		}
+		return r
+	}
+	g = g.monic(p)
+	// h = x^p - x mod g
+	h := pmod{0, 1}.powMod(p, g, p)
+	h = h.sub(pmod{0, 1}, p)
+	lin := gcd(g, h, p)
+	return lin.split(p, r)
+}
+
+// Linear is a form A·n + B, for example Linear{2^10, 1} for Proth numbers
+// n·2^10 + 1.
+type Linear struct {
+	A, B *big.Int
+}
+
+// Eval satisfies Form.
+func (f Linear) Eval(z *big.Int, n uint64) *big.Int {
+	var nb big.Int
+	return z.Add(z.Mul(f.A, nb.SetUint64(n)), f.B)
+}
+
+// Float64 satisfies Form.
+func (f Linear) Float64(n uint64) float64 {
+	a, _ := f.A.Float64()
+	b, _ := f.B.Float64()
+	return a*float64(n) + b
+}
+
+// Roots satisfies Form.
+func (f Linear) Roots(p uint64) []uint64 {
+	pb := new(big.Int).SetUint64(p)
+	a := new(big.Int).Mod(f.A, pb)
+	b := new(big.Int).Mod(f.B, pb)
+	if a.Sign() == 0 {
+		if b.Sign() != 0 {
+			return nil
+		}
+		r := make([]uint64, p)
+		for i := range r {
+			r[i] = uint64(i)
+		}
+		return r
+	}
+	// n ≡ -B/A (mod p)
+	a.ModInverse(a, pb)
+	b.Sub(pb, b)
+	return []uint64{a.Mod(a.Mul(a, b), pb).Uint64()}
+}
+
+// Visitor function passed to Iterate.
+//
+// The visitor is called with n and the prime value f(n).
+// It may retain v.  It should return false to continue iteration,
+// true to terminate iteration.
+type Visitor func(n uint64, v *big.Int) (terminate bool)
+
+// Sieve type holds a form and the roots of the form for each sieving prime.
+type Sieve struct {
+	Form   Form
+	Primes []uint64
+	// Reps is passed to big.Int.ProbablyPrime.  The zero value
+	// runs only the Baillie-PSW test.
+	Reps  int
+	roots [][]uint64
+}
+
+// New constructs a Sieve for form f with sieving primes up to b from g.
+//
+// New returns nil if b > g.Limit().
+func New(f Form, g prime.Generator, b uint64) *Sieve {
+	ps := prime.Primes(g, 2, b)
+	if ps == nil && b > g.Limit() {
+		return nil
+	}
+	s := &Sieve{Form: f, Primes: ps, roots: make([][]uint64, len(ps))}
+	for i, p := range ps {
+		s.roots[i] = f.Roots(p)
+	}
+	return s
+}
+
+// blockSize is the number of n sieved at a time.
+const blockSize = 1 << 16
+
+// Iterate visits n between min and max inclusive for which f(n) is prime,
+// in increasing order of n.
+func (s *Sieve) Iterate(min, max uint64, visitor Visitor) {
+	if min > max || len(s.Primes) == 0 {
+		return
+	}
+	pMax := s.Primes[len(s.Primes)-1]
+	// values less than pMax² that survive the sieve need no further test.
+	bound := new(big.Int).SetUint64(pMax)
+	bound.Mul(bound, bound)
+
+	// next holds, for each prime and root, the next n to cross off.
+	next := make([][]uint64, len(s.Primes))
+	for i, p := range s.Primes {
+		next[i] = make([]uint64, len(s.roots[i]))
+		for j, r := range s.roots[i] {
+			next[i][j] = min + (r+p-min%p)%p
+		}
+	}
+
+	var comp, small [blockSize / 64]uint64
+	var v big.Int
+	for b0 := min; ; b0 += blockSize {
+		nb := max - b0 + 1
+		if nb > blockSize || nb == 0 {
+			nb = blockSize
+		}
+		// n where f(n) might be as small as a sieving prime.  these are not
+		// crossed off when f(n) is the prime itself.
+		for j := uint64(0); j < nb; j++ {
+			comp[j>>6] &^= 1 << (j & 63)
+			if math.Abs(s.Form.Float64(b0+j)) <= float64(pMax)+1 {
+				small[j>>6] |= 1 << (j & 63)
+			} else {
+				small[j>>6] &^= 1 << (j & 63)
+			}
+		}
+		for i, p := range s.Primes {
+			for k, n := range next[i] {
+				j := n - b0
+				for ; j < nb; j += p {
+					if small[j>>6]&(1<<(j&63)) != 0 {
+						s.Form.Eval(&v, b0+j)
+						if v.IsUint64() && v.Uint64() == p {
+							continue
+						}
+					}
+					comp[j>>6] |= 1 << (j & 63)
+				}
+				next[i][k] = b0 + j
+			}
+		}
+		for j := uint64(0); j < nb; j++ {
+			if comp[j>>6]&(1<<(j&63)) != 0 {
+				continue
+			}
+			n := b0 + j
+			z := s.Form.Eval(new(big.Int), n)
+			if z.Cmp(big.NewInt(1)) <= 0 {
+				continue
+			}
+			if (z.Cmp(bound) < 0 || z.ProbablyPrime(s.Reps)) && visitor(n, z) {
+				return
+			}
+		}
+		if max-b0 < blockSize {
+			return
+		}
+	}
+}
+
+// Report compares a count of prime values to the Bateman-Horn prediction.
+type Report struct {
+	Count    uint64  // number of n in range with f(n) prime
+	Expected float64 // Bateman-Horn prediction, C·Σ 1/ln f(n)
+	C        float64 // Bateman-Horn constant, product over sieving primes
+}
+
+// Count counts n between min and max inclusive for which f(n) is prime,
+// and computes the Bateman-Horn prediction for the same range.
+//
+// The constant C is the product over sieving primes p of
+// (1 - ω(p)/p) / (1 - 1/p), where ω(p) is the number of roots of f mod p.
+// The sum is over n in the range with f(n) > 1.  The prediction assumes
+// f is irreducible with positive leading coefficient.
+func (s *Sieve) Count(min, max uint64) (r Report) {
+	s.Iterate(min, max, func(uint64, *big.Int) (terminate bool) {
+		r.Count++
+		return
+	})
+	r.C = 1
+	for i, p := range s.Primes {
+		r.C *= (1 - float64(len(s.roots[i]))/float64(p)) / (1 - 1/float64(p))
+	}
+	var sum float64
+	for n := min; n <= max; n++ {
+		if v := s.Form.Float64(n); v > 1 {
+			sum += 1 / math.Log(v)
+		}
+		if n == max {
+			break
+		}
+	}
+	r.Expected = r.C * sum
+	return
+}
@@ -0,0 +1,94 @@
+package poly_test
+
+import (
+	"math/big"
+	"sort"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/poly"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// Roots found by Cantor-Zassenhaus should match a brute force search.
+func TestRoots(t *testing.T) {
+	for _, f := range []poly.Poly{
+		{1, 0, 1},
+		{-2, 0, 0, 1},
+		{6, -5, 1},
+		{1, 1, 1, 1, 1},
+		{0, 0, 1},
+		{3, 0, 0, 0, 7},
+	} {
+		for _, p := range []uint64{257, 263, 1009, 7919, 65537} {
+			r := f.Roots(p)
+			sort.Slice(r, func(i, j int) bool { return r[i] < r[j] })
+			var want []uint64
+			var z, pb big.Int
+			pb.SetUint64(p)
+			for x := uint64(0); x < p; x++ {
+				if z.Mod(f.Eval(&z, x), &pb).Sign() == 0 {
+					want = append(want, x)
+				}
+			}
+			if len(r) != len(want) {
+				t.Errorf("%v roots mod %d = %v, want %v", f, p, r, want)
+				continue
+			}
+			for i, x := range want {
+				if r[i] != x {
+					t.Errorf("%v roots mod %d = %v, want %v", f, p, r, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+// OEIS A083844, number of primes n²+1 for n <= 10^k.
+func TestSquarePlusOne(t *testing.T) {
+	s := poly.New(poly.Poly{1, 0, 1}, sieve.New(1e4), 1e4)
+	for _, tc := range []struct{ max, count uint64 }{
+		{10, 5}, {100, 19}, {1000, 112}, {1e4, 841}, {1e5, 6656},
+	} {
+		r := s.Count(1, tc.max)
+		if r.Count != tc.count {
+			t.Errorf("primes n²+1, n <= %d: %d, want %d",
+				tc.max, r.Count, tc.count)
+		}
+		if tc.max == 1e5 {
+			// the Hardy-Littlewood constant for n²+1 is about 1.3728
+			if r.C < 1.36 || r.C > 1.39 {
+				t.Errorf("C = %f, want about 1.3728", r.C)
+			}
+			if d := r.Expected/float64(r.Count) - 1; d < -.05 || d > .05 {
+				t.Errorf("Expected = %f, count %d", r.Expected, r.Count)
+			}
+		}
+	}
+}
+
+func TestProth(t *testing.T) {
+	a := big.NewInt(1 << 40)
+	s := poly.New(poly.Linear{a, big.NewInt(1)}, sieve.New(1e4), 1e4)
+	var got []uint64
+	s.Iterate(1, 2000, func(n uint64, v *big.Int) (terminate bool) {
+		got = append(got, n)
+		return
+	})
+	var want []uint64
+	var z big.Int
+	for n := uint64(1); n <= 2000; n++ {
+		z.Add(z.Mul(a, z.SetUint64(n)), big.NewInt(1))
+		if z.ProbablyPrime(0) {
+			want = append(want, n)
+		}
+	}
+	if len(got) != len(want) {
+		t.Fatalf("found %d Proth primes, want %d", len(got), len(want))
+	}
+	for i, n := range want {
+		if got[i] != n {
+			t.Fatalf("Proth prime %d: n = %d, want %d", i, got[i], n)
+		}
+	}
+}
@@ -8,6 +8,12 @@
 // for generators with tradeoffs of time, space, and complexity.
 package prime
 
+import (
+	"math/big"
+
+	"github.com/soniakeys/integer/prime/bounds"
+)
+
 // Generator is a minimal interface for prime number generators.
 // Types implementing Generator may of course have additional useful methods.
 type Generator interface {
@@ -32,6 +38,34 @@ type Generator interface {
 	Iterate(min, max uint64, visitor Visitor) (ok bool)
 }
 
+// ReverseIterator is an optional interface for generators that can
+// iterate in decreasing order.
+type ReverseIterator interface {
+	// IterateDown should iterate over the range of max down to min,
+	// inclusive, and call the visitor function for each prime number,
+	// largest first.
+	//
+	// Return values and handling of the visitor function are as for
+	// Generator.Iterate.
+	IterateDown(max, min uint64, visitor Visitor) (ok bool)
+}
+
+// Collector is an optional interface for generators that can collect
+// primes of a range into a slice more efficiently than by iteration.
+type Collector interface {
+	// Primes should return a slice containing primes between min and
+	// max inclusive, or nil if max > Limit().
+	Primes(min, max uint64) []uint64
+}
+
+// Counter is an optional interface for generators that can count primes
+// of a range more efficiently than by iteration.
+type Counter interface {
+	// Count should return the number of primes between min and max
+	// inclusive, or 0 if max > Limit().
+	Count(min, max uint64) uint64
+}
+
 // Visitor function passed to Iterate method of a Generator.
 //
 // A visitor function should return false to continue iteration.
@@ -43,15 +77,18 @@ type Visitor func(prime uint64) (terminate bool)
 // range clause.  The channel returns primes in the range between a minimum
 // and maximum value, inclusive.
 //
-// The variadic bounds can be 0, 1, or 2 paramaters.  The first, if present,
+// The variadic minMax can be 0, 1, or 2 paramaters.  The first, if present,
 // is the minimum value of prime number.  The second, if present, is the
 // maximum.  If no maximum is given, the default is the value returned by
 // Limit().  If no minimum is given, the default is 2.
 //
 // Iterator returns nil if more than 3 parameters total are given,
 // or if the specified maximum is greater than Limit().
-func Iterator(pg Generator, bounds ...uint64) chan uint64 {
-	min, max, ok := parameterBounds(pg, bounds)
+//
+// The goroutine sending on the channel runs until all primes of the range
+// are received.  If you might stop early, use All or IteratorContext.
+func Iterator(pg Generator, minMax ...uint64) chan uint64 {
+	min, max, ok := parameterBounds(pg, minMax)
 	if !ok {
 		return nil
 	}
@@ -68,7 +105,7 @@ func Iterator(pg Generator, bounds ...uint64) chan uint64 {
 
 // Primes returns a slice containing prime numbers in the specified range.
 //
-// The variadic bounds can be 0, 1, or 2 paramaters.  The first, if present,
+// The variadic minMax can be 0, 1, or 2 paramaters.  The first, if present,
 // is the minimum value of prime number.  The second, if present, is the
 // maximum.  If no maximum is given, the default is the value returned by
 // Limit().  If no minimum is given, the default is 0.
@@ -76,29 +113,60 @@ func Iterator(pg Generator, bounds ...uint64) chan uint64 {
 // Primes returns nil if more than 3 parameters total are given,
 // or if the specified maximum is greater than Limit().
 //
+// If pg is a Collector, its Primes method is used.  Otherwise if pg is a
+// Counter, the result is allocated with capacity from its Count method.
+// Otherwise capacity is from the proven upper bound bounds.PiRange, so that
+// primes are generated in a single pass, if that is small or no more than
+// twice a proven lower bound on the count.  If not, as for short ranges of
+// large numbers, the bound could be far too large and primes are instead
+// counted in a first pass.
+//
 // Note well!  In the case of a stream generator with no limit,
 // calling Primes with no specified maximum is an attempt to generate
 // all primes < MaxInt64.
-func Primes(pg Generator, bounds ...uint64) []uint64 {
-	min, max, ok := parameterBounds(pg, bounds)
+func Primes(pg Generator, minMax ...uint64) []uint64 {
+	min, max, ok := parameterBounds(pg, minMax)
 	if !ok {
 		return nil
 	}
-	var i int64
-	pg.Iterate(min, max, func(_ uint64) bool {
-		i++
-		return false
-	})
-	r := make([]uint64, i)
-	i = 0
+	if pc, ok := pg.(Collector); ok {
+		return pc.Primes(min, max)
+	}
+	var c uint64
+	if pc, ok := pg.(Counter); ok {
+		c = pc.Count(min, max)
+	} else if c = bounds.PiRange(min, max); c > smallAlloc &&
+		c/2 > piRangeLower(min, max) {
+		c = 0
+		pg.Iterate(min, max, func(_ uint64) bool {
+			c++
+			return false
+		})
+	}
+	r := make([]uint64, 0, c)
 	pg.Iterate(min, max, func(prime uint64) bool {
-		r[i] = prime
-		i++
+		r = append(r, prime)
 		return false
 	})
 	return r
 }
 
+// smallAlloc is a capacity Primes allocates from an upper bound alone.
+const smallAlloc = 1 << 16
+
+// piRangeLower returns a lower bound for the number of primes between min
+// and max inclusive.
+func piRangeLower(min, max uint64) uint64 {
+	l := bounds.PiLower(max)
+	if min > 2 {
+		if u := bounds.PiUpper(min - 1); u < l {
+			return l - u
+		}
+		return 0
+	}
+	return l
+}
+
 func parameterBounds(pg Generator, bounds []uint64) (min, max uint64, ok bool) {
 	switch len(bounds) {
 	case 0:
@@ -115,3 +183,149 @@ func parameterBounds(pg Generator, bounds []uint64) (min, max uint64, ok bool) {
 	}
 	return min, max, true
 }
+
+// NextPrime returns the smallest prime > n.
+//
+// Optional generators are used if they can produce the prime, the first
+// one listed being tried first.  Otherwise NextPrime steps through
+// candidates on a 2,3,5 wheel, testing each with a probable-prime test
+// that is deterministic for all uint64 values.
+//
+// NextPrime returns 0 if there is no such prime < 2^64.
+func NextPrime(n uint64, gens ...Generator) uint64 {
+	if n < 2 {
+		return 2
+	}
+	for _, g := range gens {
+		if n >= g.Limit() {
+			continue
+		}
+		// iterate over widening windows.  stream generators may size
+		// storage by the max requested.
+		var r uint64
+		lim := g.Limit()
+		for w := uint64(64); r == 0; w *= 2 {
+			max := lim
+			if lim-n > w {
+				max = n + w
+			}
+			g.Iterate(n+1, max, func(p uint64) bool {
+				r = p
+				return true
+			})
+			if max == lim {
+				break
+			}
+		}
+		if r > 0 {
+			return r
+		}
+	}
+	if n < 7 {
+		return []uint64{3, 5, 5, 7, 7}[n-2]
+	}
+	// step to the next candidate on the wheel, then continue
+	// stepping with the wheel.
+	c := n + 1
+	for wheelIndex[c%30] < 0 {
+		c++
+	}
+	for i := wheelIndex[c%30]; ; {
+		if c < n {
+			return 0 // overflow
+		}
+		if probablyPrime(c) {
+			return c
+		}
+		c += wheel30Gaps[i]
+		if i++; i == len(wheel30Gaps) {
+			i = 0
+		}
+	}
+}
+
+// PrevPrime returns the largest prime < n.
+//
+// Optional generators are used if they can produce the prime, the first
+// one listed being tried first.  A generator implementing ReverseIterator
+// finds the prime directly, others iterate over widening windows below n.
+// Otherwise PrevPrime steps backward through candidates on a 2,3,5 wheel,
+// testing each with a probable-prime test that is deterministic for
+// all uint64 values.
+//
+// PrevPrime returns 0 if n <= 2.
+func PrevPrime(n uint64, gens ...Generator) uint64 {
+	if n <= 2 {
+		return 0
+	}
+	max := n - 1
+	for _, g := range gens {
+		if max > g.Limit() {
+			continue
+		}
+		var r uint64
+		if ri, ok := g.(ReverseIterator); ok {
+			ri.IterateDown(max, 0, func(p uint64) bool {
+				r = p
+				return true
+			})
+			return r
+		}
+		for w := uint64(64); r == 0; w *= 2 {
+			min := uint64(0)
+			if max > w {
+				min = max - w
+			}
+			g.Iterate(min, max, func(p uint64) (terminate bool) {
+				r = p
+				return
+			})
+			if min == 0 {
+				break
+			}
+		}
+		return r
+	}
+	if max < 7 {
+		return []uint64{2, 3, 3, 5, 5}[max-2]
+	}
+	c := max
+	for wheelIndex[c%30] < 0 {
+		c--
+	}
+	for i := wheelIndex[c%30]; ; {
+		if probablyPrime(c) {
+			return c
+		}
+		if i == 0 {
+			i = len(wheel30Gaps)
+		}
+		i--
+		c -= wheel30Gaps[i]
+	}
+}
+
+// wheel30Gaps holds differences between successive residues mod 30
+// that are coprime to 30.  wheelIndex maps residues to indexes in
+// wheel30Gaps, or -1 for residues not coprime to 30.
+var (
+	wheel30Gaps = []uint64{6, 4, 2, 4, 2, 4, 6, 2}
+	wheelIndex  [30]int
+)
+
+func init() {
+	for r := range wheelIndex {
+		wheelIndex[r] = -1
+	}
+	r := 1
+	for i, g := range wheel30Gaps {
+		wheelIndex[r] = i
+		r += int(g)
+	}
+}
+
+// probablyPrime tests n for primality.  big.Int.ProbablyPrime(0)
+// is a Baillie-PSW test, 100% accurate for n < 2^64.
+func probablyPrime(n uint64) bool {
+	return new(big.Int).SetUint64(n).ProbablyPrime(0)
+}
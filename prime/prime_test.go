@@ -1,8 +1,11 @@
 package prime_test
 
 import (
+	"context"
 	"reflect"
+	"runtime"
 	"testing"
+	"time"
 
 	"github.com/soniakeys/integer/prime"
 	"github.com/soniakeys/integer/prime/queue"
@@ -92,3 +95,153 @@ gloop:
 		}
 	}
 }
+
+// Primes should allocate from a bound only when the bound is close.
+func TestPrimesCap(t *testing.T) {
+	for _, c := range []struct {
+		gen      prime.Generator
+		min, max uint64
+	}{
+		{queue.PQueue{}, 0, 2e6},       // bound close to count
+		{sprp.New(), 1e12, 1e12 + 1e6}, // bound far too large
+	} {
+		r := prime.Primes(c.gen, c.min, c.max)
+		var n int
+		c.gen.Iterate(c.min, c.max, func(uint64) (terminate bool) {
+			n++
+			return
+		})
+		if len(r) != n || float64(cap(r)) > 1.01*float64(n) {
+			t.Errorf("%s Primes(%d, %d): len %d, cap %d, count %d",
+				reflect.TypeOf(c.gen), c.min, c.max, len(r), cap(r), n)
+		}
+	}
+}
+
+// Compare IterateDown of each ReverseIterator to Primes reversed.
+func TestIterateDown(t *testing.T) {
+	const limit = 1000
+	reference := prime.Primes(sieve.New(limit), 0, limit)
+	for _, gen := range []prime.Generator{
+		sieve.New(limit),
+		segment.New(limit),
+		sieve.New(1e5),
+		segment.New(1e5),
+	} {
+		ri := gen.(prime.ReverseIterator)
+		for _, b := range []struct{ max, min uint64 }{
+			{limit, 0}, {997, 2}, {996, 3}, {500, 100}, {6, 5}, {4, 0},
+			{100, 100}, {1, 0}, {0, 0},
+		} {
+			var want, got []uint64
+			for i := len(reference) - 1; i >= 0; i-- {
+				if p := reference[i]; p >= b.min && p <= b.max {
+					want = append(want, p)
+				}
+			}
+			ri.IterateDown(b.max, b.min, func(p uint64) (terminate bool) {
+				got = append(got, p)
+				return
+			})
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%s.IterateDown(%d, %d) = %v, want %v",
+					reflect.TypeOf(gen), b.max, b.min, got, want)
+			}
+		}
+	}
+	if sieve.New(limit).IterateDown(limit+1, 0, nil) {
+		t.Error("IterateDown accepted max > Limit()")
+	}
+}
+
+func TestNextPrevPrime(t *testing.T) {
+	const limit = 2000
+	s := sieve.New(limit)
+	ps := prime.Primes(s)
+	for _, gens := range [][]prime.Generator{
+		nil,
+		{s},
+		{queue.PQueue{}},
+		{sprp.New()},
+	} {
+		for i, p := range ps[1 : len(ps)-1] {
+			for n := ps[i]; n < p; n++ {
+				if np := prime.NextPrime(n, gens...); np != p {
+					t.Fatalf("NextPrime(%d) = %d, want %d", n, np, p)
+				}
+			}
+			for n := p + 1; n <= ps[i+2]; n++ {
+				if pp := prime.PrevPrime(n, gens...); pp != p {
+					t.Fatalf("PrevPrime(%d) = %d, want %d", n, pp, p)
+				}
+			}
+		}
+	}
+	for _, tc := range []struct{ n, next, prev uint64 }{
+		{0, 2, 0},
+		{2, 3, 0},
+		{1 << 32, 1<<32 + 15, 1<<32 - 5},
+		{1<<64 - 59, 0, 1<<64 - 83},
+	} {
+		if p := prime.NextPrime(tc.n); p != tc.next {
+			t.Errorf("NextPrime(%d) = %d, want %d", tc.n, p, tc.next)
+		}
+		if p := prime.PrevPrime(tc.n); p != tc.prev {
+			t.Errorf("PrevPrime(%d) = %d, want %d", tc.n, p, tc.prev)
+		}
+	}
+}
+
+func TestAll(t *testing.T) {
+	s := sieve.New(1000)
+	var got []uint64
+	for p := range prime.All(s, 10, 1000) {
+		if p > 30 {
+			break
+		}
+		got = append(got, p)
+	}
+	if !reflect.DeepEqual(got, []uint64{11, 13, 17, 19, 23, 29}) {
+		t.Error(got)
+	}
+	for p := range prime.All(s, 0, 1001) {
+		t.Error("max > Limit yielded", p)
+	}
+}
+
+func TestIteratorContext(t *testing.T) {
+	s := sieve.New(1e5)
+	n0 := runtime.NumGoroutine()
+	ctx, cancel := context.WithCancel(context.Background())
+	for p := range prime.IteratorContext(ctx, s, 0, 1e5) {
+		if p > 100 {
+			break
+		}
+	}
+	var n int
+	for b := range prime.Batches(ctx, s, 0, 1e5, 1000) {
+		n += len(b)
+		break
+	}
+	if n != 1000 {
+		t.Error("batch of", n)
+	}
+	cancel()
+	// both goroutines should exit
+	for i := 0; i < 100 && runtime.NumGoroutine() > n0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if g := runtime.NumGoroutine(); g > n0 {
+		t.Errorf("%d goroutines, want %d", g, n0)
+	}
+	// uncancelled batches cover the range
+	n = 0
+	last := 0
+	for b := range prime.Batches(context.Background(), s, 0, 1e5, 1000) {
+		n += len(b)
+		last = len(b)
+	}
+	if n != 9592 || last != 592 {
+		t.Error(n, last)
+	}
+}
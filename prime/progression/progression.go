@@ -0,0 +1,255 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package progression computes primes in arithmetic progressions.
+//
+// A Progression generates primes p ≡ a (mod q) for chosen residue classes a.
+// It sieves only the chosen classes, representing a + i*q by bit i
+// of a bitmap for each class, so numbers in other classes are never
+// represented at all.  Sieving primes are taken from another generator,
+// typically a segment.Sieve.
+package progression
+
+import (
+	"math"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/xmath"
+)
+
+// Progression type holds a modulus, residue classes, and a generator
+// of sieving primes.
+//
+// Progression satisfies prime.Generator.
+type Progression struct {
+	Gen     prime.Generator
+	Q       uint64
+	Classes []uint64
+}
+
+// New constructs a Progression object for primes p ≡ a (mod q) where a is
+// one of classes.  Sieving primes are taken from g.
+//
+// Classes should be distinct and less than q.  They are sorted by New.
+// New returns nil if q is 0 or a class is not less than q.
+func New(g prime.Generator, q uint64, classes ...uint64) *Progression {
+	if q == 0 {
+		return nil
+	}
+	c := append([]uint64{}, classes...)
+	for i, a := range c {
+		if a >= q {
+			return nil
+		}
+		// insertion sort
+		for j := i; j > 0 && c[j-1] > a; j-- {
+			c[j], c[j-1] = c[j-1], c[j]
+		}
+	}
+	return &Progression{g, q, c}
+}
+
+// Limit satisfies prime.Generator.
+//
+// Sieving requires primes up to the square root of the maximum, so the limit
+// is the square of the limit of the underlying generator.  It is at most
+// math.MaxInt64, so that values a + i*q near the end of a range do not
+// overflow.
+func (pr *Progression) Limit() uint64 {
+	l := pr.Gen.Limit()
+	if l >= 1<<31 {
+		return math.MaxInt64
+	}
+	return l*l + 2*l // the largest value < (l+1)^2
+}
+
+// blockBits is the number of indexes i sieved at a time, for each class.
+const blockBits = 1 << 15
+
+// Iterate satisfies prime.Generator.
+func (pr *Progression) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > pr.Limit() {
+		return false
+	}
+	if min < 2 {
+		min = 2
+	}
+	if min > max || len(pr.Classes) == 0 {
+		return true
+	}
+	q := pr.Q
+	sp := prime.Primes(pr.Gen, 2, xmath.FloorSqrt64(max))
+
+	// index range common to all classes.  values a + i*q near the ends
+	// may be out of range and are filtered as they are visited.
+	iLo, iHi := min/q, max/q
+
+	// for each class, for each sieving prime, next index to cross off.
+	// a class sharing a factor with q holds at most one prime, a itself.
+	// such a class is marked by a nil next.
+	next := make([][]uint64, len(pr.Classes))
+	single := make([]bool, len(pr.Classes))
+	for c, a := range pr.Classes {
+		if gcd(a, q) != 1 {
+			single[c] = a >= 2 && prime.NextPrime(a-1) == a
+			continue
+		}
+		nc := make([]uint64, len(sp))
+		for k, p := range sp {
+			if q%p == 0 {
+				nc[k] = math.MaxUint64 // p never divides a + i*q
+				continue
+			}
+			// a + i*q ≡ 0 (mod p) when i ≡ r
+			r := (p - a%p) % p * inverse(q%p, p) % p
+			// start at p*p or iLo, whichever is larger
+			i0 := iLo
+			if pp := p * p; pp > a {
+				if iP := (pp - a + q - 1) / q; iP > i0 {
+					i0 = iP
+				}
+			}
+			nc[k] = i0 + (r+p-i0%p)%p
+		}
+		next[c] = nc
+	}
+
+	bm := make([][]uint64, len(pr.Classes))
+	for c := range bm {
+		bm[c] = make([]uint64, blockBits/64)
+	}
+	for b0 := iLo; b0 <= iHi; b0 += blockBits {
+		nb := iHi - b0 + 1
+		if nb > blockBits {
+			nb = blockBits
+		}
+		// sieve the block for each class
+		for c, nc := range next {
+			b := bm[c]
+			if nc == nil {
+				for w := range b {
+					b[w] = math.MaxUint64
+				}
+				if single[c] && b0 == 0 {
+					b[0] &^= 1
+				}
+				continue
+			}
+			for w := range b {
+				b[w] = 0
+			}
+			for k, p := range sp {
+				j := nc[k]
+				if j == math.MaxUint64 {
+					continue
+				}
+				for j -= b0; j < nb; j += p {
+					b[j>>6] |= 1 << (j & 63)
+				}
+				nc[k] = b0 + j
+			}
+		}
+		// visit survivors in increasing order
+		for j := uint64(0); j < nb; j++ {
+			for c, a := range pr.Classes {
+				if bm[c][j>>6]&(1<<(j&63)) != 0 {
+					continue
+				}
+				n := a + (b0+j)*q
+				switch {
+				case n < min:
+					continue
+				case n > max:
+					return true
+				}
+				if visitor(n) {
+					return true
+				}
+			}
+		}
+	}
+	return true
+}
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// inverse returns the inverse of a mod m, for a and m coprime.
+func inverse(a, m uint64) uint64 {
+	var x0, x1 int64 = 0, 1
+	b, mm := int64(m), int64(a)
+	for mm != 0 {
+		t := b / mm
+		b, mm = mm, b-t*mm
+		x0, x1 = x1, x0-t*x1
+	}
+	if x0 < 0 {
+		x0 += int64(m)
+	}
+	return uint64(x0)
+}
+
+// Counts returns π(x; q, a) for every residue a from 0 to q-1, using primes
+// from g.  Element a of the result is the number of primes p <= x with
+// p ≡ a (mod q).
+//
+// Counts returns nil if q is 0 or if x > g.Limit().
+func Counts(g prime.Generator, q, x uint64) []uint64 {
+	if q == 0 {
+		return nil
+	}
+	r := make([]uint64, q)
+	if !g.Iterate(2, x, func(p uint64) (terminate bool) {
+		r[p%q]++
+		return
+	}) {
+		return nil
+	}
+	return r
+}
+
+// Checkpoint holds counts of primes <= X in each class of a Progression,
+// in the order of Classes.
+type Checkpoint struct {
+	X      uint64
+	Counts []uint64
+}
+
+// Race runs a prime race between the classes of a Progression.
+//
+// Checkpoints must be increasing.  Race returns running counts for each
+// class at each checkpoint, or nil if the last checkpoint is greater than
+// Limit().
+func (pr *Progression) Race(checkpoints ...uint64) []Checkpoint {
+	if len(checkpoints) == 0 {
+		return nil
+	}
+	index := map[uint64]int{}
+	for c, a := range pr.Classes {
+		index[a] = c
+	}
+	r := make([]Checkpoint, len(checkpoints))
+	counts := make([]uint64, len(pr.Classes))
+	x := 0
+	snap := func() {
+		r[x] = Checkpoint{checkpoints[x], append([]uint64{}, counts...)}
+		x++
+	}
+	if !pr.Iterate(0, checkpoints[len(checkpoints)-1], func(p uint64) bool {
+		for x < len(checkpoints) && p > checkpoints[x] {
+			snap()
+		}
+		counts[index[p%pr.Q]]++
+		return false
+	}) {
+		return nil
+	}
+	for x < len(checkpoints) {
+		snap()
+	}
+	return r
+}
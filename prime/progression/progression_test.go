@@ -0,0 +1,89 @@
+package progression_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/progression"
+	"github.com/soniakeys/integer/prime/queue"
+	"github.com/soniakeys/integer/prime/segment"
+)
+
+// Compare Iterate to filtered output of the underlying sieve.
+func TestIterate(t *testing.T) {
+	const limit = 2e5
+	s := segment.New(limit)
+	ps := prime.Primes(s)
+	for _, tc := range []struct {
+		q       uint64
+		classes []uint64
+	}{
+		{1, []uint64{0}},
+		{4, []uint64{3, 1}},
+		{6, []uint64{1, 2, 3, 5}},
+		{10, []uint64{3, 7}},
+		{30030, []uint64{1, 13, 30029}},
+		{100003, []uint64{2, 5}},
+	} {
+		pr := progression.New(s, tc.q, tc.classes...)
+		for _, b := range []struct{ min, max uint64 }{
+			{0, limit}, {2, 3}, {1000, 1e5}, {99990, 100020},
+		} {
+			var want []uint64
+			for _, p := range ps {
+				if p < b.min || p > b.max {
+					continue
+				}
+				for _, a := range tc.classes {
+					if p%tc.q == a {
+						want = append(want, p)
+					}
+				}
+			}
+			got := prime.Primes(pr, b.min, b.max)
+			if len(got) == 0 && len(want) == 0 {
+				continue
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("q %d classes %v (%d, %d): found %d primes, want %d",
+					tc.q, tc.classes, b.min, b.max, len(got), len(want))
+			}
+		}
+	}
+	if l := progression.New(s, 4, 1).Limit(); l < limit*limit {
+		t.Errorf("Limit() = %d", l)
+	}
+}
+
+func TestRace(t *testing.T) {
+	s := segment.New(1e6)
+	c := progression.Counts(s, 4, 1e6)
+	// OEIS A007350, Chebyshev's bias: 3 mod 4 leads at 1e6.
+	if c[1] != 39175 || c[3] != 39322 {
+		t.Errorf("Counts(4, 1e6) = %v", c)
+	}
+	r := progression.New(segment.New(1e3), 4, 1, 3).Race(100, 1e4, 1e6)
+	if len(r) != 3 {
+		t.Fatalf("Race returned %d checkpoints", len(r))
+	}
+	if !reflect.DeepEqual(r[2].Counts, []uint64{c[1], c[3]}) {
+		t.Errorf("Race at 1e6 = %v, want %d %d", r[2].Counts, c[1], c[3])
+	}
+	if !reflect.DeepEqual(r[0].Counts, []uint64{11, 13}) {
+		t.Errorf("Race at 100 = %v, want [11 13]", r[0].Counts)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	if progression.Counts(segment.New(100), 0, 100) != nil {
+		t.Error("Counts accepted q = 0")
+	}
+	if l := progression.New(queue.PQueue{}, 4, 1).Limit(); l != math.MaxInt64 {
+		t.Errorf("Limit = %d, want MaxInt64", l)
+	}
+	if l := progression.New(segment.New(1000), 4, 1).Limit(); l != 1000*1002 {
+		t.Errorf("Limit = %d, want %d", l, 1000*1002)
+	}
+}
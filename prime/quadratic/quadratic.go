@@ -0,0 +1,436 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package quadratic computes Gaussian and Eisenstein primes.
+//
+// Gaussian integers are a+bi, Eisenstein integers are a+bω where ω is a
+// primitive cube root of unity.  Their primes are classified by the rational
+// primes from a prime.Generator.  For Gaussian integers, 2 ramifies as
+// -i(1+i)², p ≡ 1 (mod 4) splits into two primes of norm p, and
+// p ≡ 3 (mod 4) is inert, remaining prime with norm p².  For Eisenstein
+// integers, 3 ramifies, p ≡ 1 (mod 3) splits, and p ≡ 2 (mod 3) is inert.
+//
+// Split primes are found with Cornacchia's algorithm.
+//
+// Coordinates are int64, and norms must not exceed 2^63-1.
+package quadratic
+
+import (
+	"math/bits"
+	"sort"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/xmath"
+)
+
+// Gaussian represents the Gaussian integer A+Bi.
+type Gaussian struct {
+	A, B int64
+}
+
+// Norm returns A² + B².
+func (z Gaussian) Norm() uint64 {
+	return uint64(z.A*z.A + z.B*z.B)
+}
+
+// Mul returns the product z·w.
+func (z Gaussian) Mul(w Gaussian) Gaussian {
+	return Gaussian{z.A*w.A - z.B*w.B, z.A*w.B + z.B*w.A}
+}
+
+// Conj returns the complex conjugate A-Bi.
+func (z Gaussian) Conj() Gaussian {
+	return Gaussian{z.A, -z.B}
+}
+
+// Div returns z/w and true if w divides z, otherwise false.
+func (z Gaussian) Div(w Gaussian) (q Gaussian, ok bool) {
+	n := int64(w.Norm())
+	if n == 0 {
+		return
+	}
+	m := z.Mul(w.Conj())
+	if m.A%n != 0 || m.B%n != 0 {
+		return
+	}
+	return Gaussian{m.A / n, m.B / n}, true
+}
+
+// Normal returns the associate of z in the first quadrant, with A > 0 and
+// B >= 0, and the unit u such that z = u·Normal.
+func (z Gaussian) Normal() (n, u Gaussian) {
+	n, u = z, Gaussian{1, 0}
+	if z == (Gaussian{}) {
+		return
+	}
+	for !(n.A > 0 && n.B >= 0) {
+		// multiply n by -i, u by i
+		n = Gaussian{n.B, -n.A}
+		u = u.Mul(Gaussian{0, 1})
+	}
+	return
+}
+
+// Eisenstein represents the Eisenstein integer A+Bω.
+type Eisenstein struct {
+	A, B int64
+}
+
+// Norm returns A² - AB + B².
+func (z Eisenstein) Norm() uint64 {
+	return uint64(z.A*z.A - z.A*z.B + z.B*z.B)
+}
+
+// Mul returns the product z·w.
+func (z Eisenstein) Mul(w Eisenstein) Eisenstein {
+	// ω² = -1 - ω
+	return Eisenstein{z.A*w.A - z.B*w.B, z.A*w.B + z.B*w.A - z.B*w.B}
+}
+
+// Conj returns the complex conjugate A+Bω̄ = (A-B) - Bω.
+func (z Eisenstein) Conj() Eisenstein {
+	return Eisenstein{z.A - z.B, -z.B}
+}
+
+// Div returns z/w and true if w divides z, otherwise false.
+func (z Eisenstein) Div(w Eisenstein) (q Eisenstein, ok bool) {
+	n := int64(w.Norm())
+	if n == 0 {
+		return
+	}
+	m := z.Mul(w.Conj())
+	if m.A%n != 0 || m.B%n != 0 {
+		return
+	}
+	return Eisenstein{m.A / n, m.B / n}, true
+}
+
+// Normal returns the associate of z in the sector 0 <= arg < 60°,
+// with A > B >= 0, and the unit u such that z = u·Normal.
+func (z Eisenstein) Normal() (n, u Eisenstein) {
+	n, u = z, Eisenstein{1, 0}
+	if z == (Eisenstein{}) {
+		return
+	}
+	for !(n.A > n.B && n.B >= 0) {
+		// multiply n by -ω, a rotation of -60°, and u by -ω² = 1+ω.
+		n = n.Mul(Eisenstein{0, -1})
+		u = u.Mul(Eisenstein{1, 1})
+	}
+	return
+}
+
+// SplitGaussian returns a Gaussian prime of norm p, for prime p = 2 or
+// p ≡ 1 (mod 4).  The other is its conjugate.
+//
+// SplitGaussian returns false for other p.
+func SplitGaussian(p uint64) (Gaussian, bool) {
+	if p == 2 {
+		return Gaussian{1, 1}, true
+	}
+	if p%4 != 1 {
+		return Gaussian{}, false
+	}
+	x, y, ok := cornacchia(1, p)
+	if !ok {
+		return Gaussian{}, false
+	}
+	return Gaussian{int64(x), int64(y)}, true
+}
+
+// SplitEisenstein returns an Eisenstein prime of norm p, for prime p = 3 or
+// p ≡ 1 (mod 3).  The other is its conjugate.
+//
+// SplitEisenstein returns false for other p.
+func SplitEisenstein(p uint64) (Eisenstein, bool) {
+	if p == 3 {
+		return Eisenstein{2, 1}, true
+	}
+	if p%3 != 1 {
+		return Eisenstein{}, false
+	}
+	// x² + 3y² = p, and x + y√-3 = (x+y) + 2yω.
+	x, y, ok := cornacchia(3, p)
+	if !ok {
+		return Eisenstein{}, false
+	}
+	n, _ := Eisenstein{int64(x + y), int64(2 * y)}.Normal()
+	return n, true
+}
+
+// cornacchia solves x² + d·y² = p for prime p.
+func cornacchia(d, p uint64) (x, y uint64, ok bool) {
+	r, ok := sqrtMod(p-d%p, p)
+	if !ok {
+		return
+	}
+	if r <= p/2 {
+		r = p - r
+	}
+	a, b := p, r
+	for sq := xmath.FloorSqrt64(p); b > sq; {
+		a, b = b, a%b
+	}
+	// now b² < p
+	rem := p - b*b
+	if rem%d != 0 {
+		return 0, 0, false
+	}
+	rem /= d
+	s := xmath.FloorSqrt64(rem)
+	if s*s != rem {
+		return 0, 0, false
+	}
+	return b, s, true
+}
+
+// sqrtMod returns r with r² ≡ a (mod p), by the Tonelli-Shanks algorithm.
+func sqrtMod(a, p uint64) (uint64, bool) {
+	a %= p
+	if p == 2 || a == 0 {
+		return a, true
+	}
+	if powMod(a, (p-1)/2, p) != 1 {
+		return 0, false
+	}
+	q, s := p-1, 0
+	for q&1 == 0 {
+		q >>= 1
+		s++
+	}
+	// find a non-residue
+	z := uint64(2)
+	for powMod(z, (p-1)/2, p) != p-1 {
+		z++
+	}
+	c := powMod(z, q, p)
+	r := powMod(a, (q+1)/2, p)
+	t := powMod(a, q, p)
+	m := s
+	for t != 1 {
+		i, t2 := 0, t
+		for t2 != 1 {
+			t2 = mulMod(t2, t2, p)
+			i++
+		}
+		b := c
+		for j := 0; j < m-i-1; j++ {
+			b = mulMod(b, b, p)
+		}
+		r = mulMod(r, b, p)
+		c = mulMod(b, b, p)
+		t = mulMod(t, c, p)
+		m = i
+	}
+	return r, true
+}
+
+func mulMod(a, b, n uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, r := bits.Div64(hi%n, lo, n)
+	return r
+}
+
+func powMod(a, e, n uint64) uint64 {
+	x := uint64(1)
+	for ; e > 0; e >>= 1 {
+		if e&1 != 0 {
+			x = mulMod(x, a, n)
+		}
+		a = mulMod(a, a, n)
+	}
+	return x
+}
+
+// FactorGaussian factors z into Gaussian primes, using rational primes
+// from g.  It returns a unit u and normalized primes such that z is u times
+// the product of the primes.  Primes are ordered by norm.
+//
+// FactorGaussian returns false if z is 0 or if g cannot supply primes up to
+// the square root of the norm of z.
+func FactorGaussian(g prime.Generator, z Gaussian) (u Gaussian, f []Gaussian, ok bool) {
+	n := z.Norm()
+	if n == 0 {
+		return
+	}
+	ok = factorNorm(g, n, func(p uint64) {
+		var pis []Gaussian
+		if p%4 == 3 {
+			pis = []Gaussian{{int64(p), 0}}
+		} else {
+			// the split prime and its conjugate, which may be associates.
+			pi, _ := SplitGaussian(p)
+			pis = []Gaussian{pi}
+			if c, _ := pi.Conj().Normal(); c != pi {
+				pis = append(pis, c)
+			}
+		}
+		for _, pi := range pis {
+			for {
+				q, d := z.Div(pi)
+				if !d {
+					break
+				}
+				z = q
+				f = append(f, pi)
+			}
+		}
+	})
+	// inert primes have norm p², so rational prime order is not
+	// norm order.
+	sort.SliceStable(f, func(i, j int) bool {
+		return f[i].Norm() < f[j].Norm()
+	})
+	// what remains is a unit
+	return z, f, ok
+}
+
+// FactorEisenstein factors z into Eisenstein primes, using rational primes
+// from g.  It returns a unit u and normalized primes such that z is u times
+// the product of the primes.  Primes are ordered by norm.
+//
+// FactorEisenstein returns false if z is 0 or if g cannot supply primes up
+// to the square root of the norm of z.
+func FactorEisenstein(g prime.Generator, z Eisenstein) (u Eisenstein, f []Eisenstein, ok bool) {
+	n := z.Norm()
+	if n == 0 {
+		return
+	}
+	ok = factorNorm(g, n, func(p uint64) {
+		var pis []Eisenstein
+		if p%3 == 2 {
+			pis = []Eisenstein{{int64(p), 0}}
+		} else {
+			// the split prime and its conjugate, which may be associates.
+			pi, _ := SplitEisenstein(p)
+			pis = []Eisenstein{pi}
+			if c, _ := pi.Conj().Normal(); c != pi {
+				pis = append(pis, c)
+			}
+		}
+		for _, pi := range pis {
+			for {
+				q, d := z.Div(pi)
+				if !d {
+					break
+				}
+				z = q
+				f = append(f, pi)
+			}
+		}
+	})
+	// inert primes have norm p², so rational prime order is not
+	// norm order.
+	sort.SliceStable(f, func(i, j int) bool {
+		return f[i].Norm() < f[j].Norm()
+	})
+	return z, f, ok
+}
+
+// factorNorm calls f for each distinct rational prime dividing n,
+// in increasing order.
+func factorNorm(g prime.Generator, n uint64, f func(p uint64)) bool {
+	r := xmath.FloorSqrt64(n)
+	if r > g.Limit() {
+		return false
+	}
+	g.Iterate(2, r, func(p uint64) bool {
+		if p > n/p {
+			return true
+		}
+		if n%p == 0 {
+			f(p)
+			for n%p == 0 {
+				n /= p
+			}
+		}
+		return false
+	})
+	if n > 1 {
+		f(n)
+	}
+	return true
+}
+
+// IterateGaussian visits Gaussian primes with norm between min and max
+// inclusive, in increasing order of norm, using rational primes from g.
+// One normalized prime is visited from each class of associates.  For
+// split primes, the prime returned by SplitGaussian is visited first,
+// then its normalized conjugate.
+//
+// IterateGaussian returns false if max > g.Limit().
+func IterateGaussian(g prime.Generator, min, max uint64, visitor func(Gaussian) (terminate bool)) bool {
+	return iterate(g, min, max, 4, 3, func(p uint64) bool {
+		if p%4 == 3 {
+			return visitor(Gaussian{int64(p), 0})
+		}
+		z, _ := SplitGaussian(p)
+		if visitor(z) {
+			return true
+		}
+		if c, _ := z.Conj().Normal(); c != z {
+			return visitor(c)
+		}
+		return false
+	})
+}
+
+// IterateEisenstein visits Eisenstein primes with norm between min and max
+// inclusive, in increasing order of norm, using rational primes from g.
+// One normalized prime is visited from each class of associates.  For
+// split primes, the prime returned by SplitEisenstein is visited first,
+// then its normalized conjugate.
+//
+// IterateEisenstein returns false if max > g.Limit().
+func IterateEisenstein(g prime.Generator, min, max uint64, visitor func(Eisenstein) (terminate bool)) bool {
+	return iterate(g, min, max, 3, 2, func(p uint64) bool {
+		if p%3 == 2 {
+			return visitor(Eisenstein{int64(p), 0})
+		}
+		z, _ := SplitEisenstein(p)
+		if visitor(z) {
+			return true
+		}
+		if c, _ := z.Conj().Normal(); c != z {
+			return visitor(c)
+		}
+		return false
+	})
+}
+
+// iterate merges split primes p, with norm p, and inert primes q ≡ inert
+// (mod m), with norm q², calling visit with each rational prime in order
+// of norm.
+func iterate(g prime.Generator, min, max, m, inert uint64, visit func(p uint64) bool) bool {
+	if max > g.Limit() {
+		return false
+	}
+	var qs []uint64
+	g.Iterate(xmath.FloorSqrt64(min), xmath.FloorSqrt64(max), func(q uint64) (terminate bool) {
+		if q%m == inert && q*q >= min {
+			qs = append(qs, q)
+		}
+		return
+	})
+	var terminated bool
+	g.Iterate(min, max, func(p uint64) bool {
+		for len(qs) > 0 && qs[0]*qs[0] < p {
+			if visit(qs[0]) {
+				terminated = true
+				return true
+			}
+			qs = qs[1:]
+		}
+		if p%m != inert && visit(p) {
+			terminated = true
+			return true
+		}
+		return false
+	})
+	for ; !terminated && len(qs) > 0; qs = qs[1:] {
+		if visit(qs[0]) {
+			return true
+		}
+	}
+	return true
+}
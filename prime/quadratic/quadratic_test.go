@@ -0,0 +1,129 @@
+package quadratic_test
+
+import (
+	"testing"
+
+	"github.com/soniakeys/integer/prime/quadratic"
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// OEIS A055025, norms of Gaussian primes, one per associate class
+// with conjugates both counted.
+var gaussNorms = []uint64{2, 5, 5, 9, 13, 13, 17, 17, 29, 29, 37, 37, 41, 41,
+	49, 53, 53, 61, 61, 73, 73, 89, 89, 97, 97}
+
+// OEIS A055664, norms of Eisenstein primes.
+var eisNorms = []uint64{3, 4, 7, 7, 13, 13, 19, 19, 25, 31, 31, 37, 37, 43,
+	43, 61, 61, 67, 67, 73, 73, 79, 79, 97, 97}
+
+func TestIterate(t *testing.T) {
+	s := sieve.New(1000)
+	var gn []uint64
+	quadratic.IterateGaussian(s, 0, 100, func(z quadratic.Gaussian) bool {
+		if n, _ := z.Normal(); n != z {
+			t.Errorf("%v not normal", z)
+		}
+		gn = append(gn, z.Norm())
+		return false
+	})
+	if !equal(gn, gaussNorms) {
+		t.Errorf("Gaussian norms = %v", gn)
+	}
+	var en []uint64
+	quadratic.IterateEisenstein(s, 0, 100, func(z quadratic.Eisenstein) bool {
+		if n, _ := z.Normal(); n != z {
+			t.Errorf("%v not normal", z)
+		}
+		en = append(en, z.Norm())
+		return false
+	})
+	if !equal(en, eisNorms) {
+		t.Errorf("Eisenstein norms = %v", en)
+	}
+	if quadratic.IterateGaussian(s, 0, 1001, nil) {
+		t.Error("IterateGaussian accepted max > Limit()")
+	}
+}
+
+func equal(a, b []uint64) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSplit(t *testing.T) {
+	s := sieve.New(1e5)
+	s.Iterate(2, 1e5, func(p uint64) (terminate bool) {
+		if z, ok := quadratic.SplitGaussian(p); ok != (p == 2 || p%4 == 1) ||
+			ok && z.Norm() != p {
+			t.Errorf("SplitGaussian(%d) = %v, %t", p, z, ok)
+		}
+		if z, ok := quadratic.SplitEisenstein(p); ok != (p == 3 || p%3 == 1) ||
+			ok && z.Norm() != p {
+			t.Errorf("SplitEisenstein(%d) = %v, %t", p, z, ok)
+		}
+		return
+	})
+}
+
+func TestFactor(t *testing.T) {
+	s := sieve.New(1e5)
+	for _, z := range []quadratic.Gaussian{
+		{1, 0}, {0, -1}, {2, 0}, {5, 0}, {3, 4}, {-7, 24}, {1234, -5678},
+		{65, 0}, {0, 1e5}, {6, 3},
+	} {
+		u, f, ok := quadratic.FactorGaussian(s, z)
+		if !ok {
+			t.Errorf("FactorGaussian(%v) failed", z)
+			continue
+		}
+		if u.Norm() != 1 {
+			t.Errorf("FactorGaussian(%v) unit = %v", z, u)
+		}
+		p := u
+		for _, pi := range f {
+			p = p.Mul(pi)
+		}
+		if p != z {
+			t.Errorf("FactorGaussian(%v) = %v %v", z, u, f)
+		}
+		for i := 1; i < len(f); i++ {
+			if f[i].Norm() < f[i-1].Norm() {
+				t.Errorf("FactorGaussian(%v) = %v not ordered by norm", z, f)
+				break
+			}
+		}
+	}
+	for _, z := range []quadratic.Eisenstein{
+		{1, 0}, {0, -1}, {3, 0}, {7, 0}, {2, 1}, {91, -12}, {1234, -5678},
+		{0, 1e5}, {4, 2},
+	} {
+		u, f, ok := quadratic.FactorEisenstein(s, z)
+		if !ok {
+			t.Errorf("FactorEisenstein(%v) failed", z)
+			continue
+		}
+		if u.Norm() != 1 {
+			t.Errorf("FactorEisenstein(%v) unit = %v", z, u)
+		}
+		p := u
+		for _, pi := range f {
+			p = p.Mul(pi)
+		}
+		if p != z {
+			t.Errorf("FactorEisenstein(%v) = %v %v", z, u, f)
+		}
+		for i := 1; i < len(f); i++ {
+			if f[i].Norm() < f[i-1].Norm() {
+				t.Errorf("FactorEisenstein(%v) = %v not ordered by norm", z, f)
+				break
+			}
+		}
+	}
+}
@@ -42,6 +42,8 @@ import (
 	"math"
 
 	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/bounds"
+	"github.com/soniakeys/integer/xmath"
 )
 
 // PQueue has no state.  Memory is only used when primes are requested.
@@ -58,6 +60,9 @@ type pMult struct {
 	pMult uint64
 }
 
+// wheelPrimes is the number of primes of the wheel, 2 and 3.
+const wheelPrimes = 2
+
 type wheel func() uint64
 
 func makeWheel(half []uint64) wheel {
@@ -100,25 +105,24 @@ func (s PQueue) Iterate(min, max uint64, visitor prime.Visitor) (ok bool) {
 		return true
 	}
 
-	// estimate number of primes < sqrt(max)
+	// bound the number of primes <= sqrt(max).
 	// this will determine the storage required by the wheel and the heap.
 	var piSM int
-	sqrtMaxF := math.Sqrt(float64(max))
-	sqrtMaxI := uint64(sqrtMaxF)
-	if max == math.MaxInt64 {
+	sqrtMaxI := xmath.FloorSqrt64(max)
+	if max >= math.MaxInt64 {
 		// this is the case of unbounded iteration.  so really, let's
 		// limit this to something reasonable and reallocate as needed.
 		// a limit of 1000 limits the inital heap to 16k of memory
 		piSM = 1000
 	} else {
-		// a quick estimate for pi(sqrt(max))
-		ln := math.Log(sqrtMaxF)
-		piSM = int(sqrtMaxF / ln * (1 + 1.2762/ln))
+		piSM = int(bounds.PiUpper(sqrtMaxI))
+	}
+	if piSM <= wheelPrimes {
+		piSM = wheelPrimes + 1
 	}
 
 	// build the wheel
 	half := []uint64{2, 4}
-	wheelPrimes := 2
 	var prime uint64
 	for {
 		prime = half[1] + 1
@@ -0,0 +1,73 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package segment
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// cacheSize returns the L2 cache size in bytes per cpu sharing it, or 0 if
+// it cannot be determined.
+func cacheSize() uint64 {
+	dirs, _ := filepath.Glob("/sys/devices/system/cpu/cpu0/cache/index*")
+	read := func(dir, name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+	for _, dir := range dirs {
+		if read(dir, "level") != "2" || read(dir, "type") == "Instruction" {
+			continue
+		}
+		size := parseSize(read(dir, "size"))
+		if n := countCpus(read(dir, "shared_cpu_list")); n > 1 {
+			size /= uint64(n)
+		}
+		return size
+	}
+	return 0
+}
+
+// parseSize parses a size such as "2048K", returning 0 if it is invalid.
+func parseSize(s string) uint64 {
+	m := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "K"):
+		m = 1 << 10
+	case strings.HasSuffix(s, "M"):
+		m = 1 << 20
+	case strings.HasSuffix(s, "G"):
+		m = 1 << 30
+	}
+	n, err := strconv.ParseUint(strings.TrimRight(s, "KMG"), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n * m
+}
+
+// countCpus counts cpus in a cpu list such as "0-3,8-11".
+func countCpus(list string) int {
+	n := 0
+	for _, r := range strings.Split(list, ",") {
+		lo, hi, found := strings.Cut(r, "-")
+		a, err := strconv.Atoi(lo)
+		if err != nil {
+			continue
+		}
+		b := a
+		if found {
+			if b, err = strconv.Atoi(hi); err != nil {
+				continue
+			}
+		}
+		n += b - a + 1
+	}
+	return n
+}
@@ -0,0 +1,11 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+//go:build !linux
+
+package segment
+
+// cacheSize returns 0, the cache size cannot be determined.
+func cacheSize() uint64 {
+	return 0
+}
@@ -0,0 +1,39 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Calseg measures segment.Sieve construction over a range of segment sizes
+// to find the best segment size on the current machine.
+//
+// The result can be passed as Options.SegmentSize.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/segment"
+)
+
+func main() {
+	n := flag.Uint64("n", 1e9, "sieve limit")
+	flag.Parse()
+	d := segment.DefaultOptions()
+	fmt.Println("Default options:", d)
+	var best segment.Options
+	var bestNs int64
+	// segment sizes from 1/16 to 4 times the cache size
+	for s := d.CacheSize / 16; s <= d.CacheSize*4; s *= 2 {
+		o := segment.Options{CacheSize: d.CacheSize, SegmentSize: s}
+		r := testing.Benchmark(func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				segment.NewOptions(*n, o)
+			}
+		})
+		fmt.Println("SegmentSize:", s, r)
+		if bestNs == 0 || r.NsPerOp() < bestNs {
+			best, bestNs = o, r.NsPerOp()
+		}
+	}
+	fmt.Println("Best SegmentSize:", best.SegmentSize)
+}
@@ -0,0 +1,189 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package segment
+
+import (
+	"math/bits"
+	"runtime"
+)
+
+// bitRange returns bit positions of the smallest candidate >= min and
+// the largest candidate <= max, for max >= 5.  ok is false if there are no
+// candidates in the range.
+func bitRange(min, max uint64) (bLo, bHi uint64, ok bool) {
+	bHi = max/density - 1
+	if 5+density*bHi-bHi&1 > max {
+		bHi--
+	}
+	if min > 5 {
+		bLo = min/density - 1
+		if 5+density*bLo-bLo&1 < min {
+			bLo++
+		}
+	}
+	return bLo, bHi, bLo <= bHi
+}
+
+// primeWord returns the word at index i of the sieve with bits set for
+// primes, masked to the bit range [bLo, bHi].
+func (s *Sieve) primeWord(i, bLo, bHi uint64) uint64 {
+	w := ^s.isComposite[i]
+	if i == bLo/bitsPerWord {
+		w &^= 1<<(bLo&mask) - 1
+	}
+	if i == bHi/bitsPerWord {
+		w &= 2<<(bHi&mask) - 1
+	}
+	return w
+}
+
+// chunks divides a range of words of the sieve into chunks, which are
+// processed in parallel.
+type chunks struct {
+	wLo, wHi uint64 // range of words, inclusive
+	per      uint64 // words per chunk
+	n        uint64 // number of chunks
+	nCpu     int
+}
+
+func newChunks(wLo, wHi uint64) chunks {
+	nWords := wHi - wLo + 1
+	nCpu := runtime.GOMAXPROCS(0)
+	// several chunks per cpu, for load balance
+	n := uint64(nCpu * 4)
+	if n > nWords {
+		n = nWords
+	}
+	per := (nWords + n - 1) / n
+	n = (nWords + per - 1) / per
+	return chunks{wLo, wHi, per, n, nCpu}
+}
+
+// words returns the range of words [start, end) of chunk c.
+func (ch chunks) words(c uint64) (start, end uint64) {
+	start = ch.wLo + c*ch.per
+	end = start + ch.per
+	if end > ch.wHi+1 {
+		end = ch.wHi + 1
+	}
+	return
+}
+
+// run calls f for each chunk on nCpu workers.
+func (ch chunks) run(f func(c uint64)) {
+	cCh := make(chan uint64)
+	doneCh := make(chan int)
+	for i := 0; i < ch.nCpu; i++ {
+		// workers
+		go func() {
+			for c := range cCh {
+				f(c)
+			}
+			doneCh <- 0
+		}()
+	}
+	for c := uint64(0); c < ch.n; c++ {
+		cCh <- c
+	}
+	close(cCh)
+	for i := 0; i < ch.nCpu; i++ {
+		<-doneCh
+	}
+}
+
+// Primes returns a slice containing primes between min and max inclusive,
+// or nil if max > Limit().  It satisfies prime.Collector.
+//
+// The words of the sieve are divided into chunks, which are processed in
+// parallel in two passes.  The first pass counts primes of each chunk
+// with popcount.  A prefix sum of counts gives each chunk its offset into
+// the result, then the second pass writes primes of each chunk directly
+// into the result.
+func (s *Sieve) Primes(min, max uint64) []uint64 {
+	if max > s.Lim {
+		return nil
+	}
+	r := []uint64{}
+	for _, p := range []uint64{2, 3} {
+		if p >= min && p <= max {
+			r = append(r, p)
+		}
+	}
+	if max < 5 {
+		return r
+	}
+	bLo, bHi, ok := bitRange(min, max)
+	if !ok {
+		return r
+	}
+	ch := newChunks(bLo/bitsPerWord, bHi/bitsPerWord)
+
+	// pass 1, count
+	off := make([]uint64, ch.n+1)
+	ch.run(func(c uint64) {
+		off[c+1] = s.countWords(c, ch, bLo, bHi)
+	})
+	// prefix sum
+	off[0] = uint64(len(r))
+	for c := uint64(1); c <= ch.n; c++ {
+		off[c] += off[c-1]
+	}
+	r = append(make([]uint64, 0, off[ch.n]), r...)[:off[ch.n]]
+
+	// pass 2, fill
+	ch.run(func(c uint64) {
+		start, end := ch.words(c)
+		j := off[c]
+		for i := start; i < end; i++ {
+			for w := s.primeWord(i, bLo, bHi); w != 0; w &= w - 1 {
+				b := i*bitsPerWord + uint64(bits.TrailingZeros64(w))
+				r[j] = 5 + density*b - b&1
+				j++
+			}
+		}
+	})
+	return r
+}
+
+// countWords counts primes in chunk c within the bit range [bLo, bHi].
+func (s *Sieve) countWords(c uint64, ch chunks, bLo, bHi uint64) uint64 {
+	start, end := ch.words(c)
+	n := 0
+	for i := start; i < end; i++ {
+		n += bits.OnesCount64(s.primeWord(i, bLo, bHi))
+	}
+	return uint64(n)
+}
+
+// Count returns the number of primes between min and max inclusive, or 0
+// if max > Limit().  It satisfies prime.Counter.
+//
+// Chunks of words are counted in parallel with popcount.
+func (s *Sieve) Count(min, max uint64) uint64 {
+	if max > s.Lim {
+		return 0
+	}
+	var n uint64
+	for _, p := range []uint64{2, 3} {
+		if p >= min && p <= max {
+			n++
+		}
+	}
+	if max < 5 {
+		return n
+	}
+	bLo, bHi, ok := bitRange(min, max)
+	if !ok {
+		return n
+	}
+	ch := newChunks(bLo/bitsPerWord, bHi/bitsPerWord)
+	counts := make([]uint64, ch.n)
+	ch.run(func(c uint64) {
+		counts[c] = s.countWords(c, ch, bLo, bHi)
+	})
+	for _, c := range counts {
+		n += c
+	}
+	return n
+}
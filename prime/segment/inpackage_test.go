@@ -0,0 +1,44 @@
+package segment
+
+import "testing"
+
+// TestBuckets sieves with tiny segments, so that most sieving primes go
+// through buckets, and compares the result with the unsegmented sieve.
+func TestBuckets(t *testing.T) {
+	const n uint64 = 3e6
+	want := new(Sieve).init(n, Options{SegmentSize: n, Workers: 1})
+	for _, seg := range []uint64{8, 1 << 10, 3 << 10, 1 << 14} {
+		got := new(Sieve).init(n, Options{SegmentSize: seg, Workers: 3})
+		// compare bits of numbers <= n
+		last := (n/density - 1) / bitsPerWord
+		for i := uint64(0); i < last; i++ {
+			if got.isComposite[i] != want.isComposite[i] {
+				t.Fatalf("segment size %d: word %d = %x, want %x",
+					seg, i, got.isComposite[i], want.isComposite[i])
+			}
+		}
+	}
+}
+
+// TestPresieve checks presieve patterns at a range of word offsets against
+// divisibility by the presieved primes.
+func TestPresieve(t *testing.T) {
+	const nw = 400
+	for _, w0 := range []uint64{0, 1, 156, 157, 10009, 1<<20 + 3} {
+		ic := make([]uint64, w0+nw)
+		presieve(ic, w0, w0+nw)
+		for b := w0 * bitsPerWord; b < (w0+nw)*bitsPerWord; b++ {
+			n := 5 + density*b - b&1
+			want := false
+			for p := uint64(5); p <= presieveMax; p += 2 {
+				if p%3 != 0 && n%p == 0 {
+					want = true
+					break
+				}
+			}
+			if got := ic[b>>log2Int]&(1<<(b&mask)) != 0; got != want {
+				t.Fatalf("w0 %d: bit for %d = %t, want %t", w0, n, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package segment
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Options tune the sieve to the cache and cpus of a machine.
+//
+// Zero valued fields take default values.  See DefaultOptions.
+type Options struct {
+	// CacheSize is the size in bytes of cache private to each worker,
+	// typically the L2 cache of a core.
+	CacheSize uint64
+	// SegmentSize is the size in bytes of a segment sieved by a worker.
+	// The default is half of CacheSize, leaving some cache for other
+	// purposes.
+	SegmentSize uint64
+	// Workers is the number of goroutines sieving segments.  The default
+	// is runtime.GOMAXPROCS(0).
+	Workers int
+}
+
+// defaultCacheSize is the cache size used where it cannot be determined
+// from the system.  It is a conservative size for an L2 cache.
+const defaultCacheSize = 256 << 10
+
+var (
+	cacheOnce     sync.Once
+	detectedCache uint64
+)
+
+// DefaultOptions returns options with all fields set to default values.
+//
+// On Linux, CacheSize is the L2 cache size of cpu0, divided among cpus
+// sharing it, as reported under /sys/devices/system/cpu/cpu0/cache.
+// Elsewhere or if the size cannot be read, it is 256KB.
+func DefaultOptions() Options {
+	return Options{}.resolve()
+}
+
+// resolve returns o with zero fields replaced by default values.
+func (o Options) resolve() Options {
+	if o.CacheSize == 0 {
+		cacheOnce.Do(func() { detectedCache = cacheSize() })
+		o.CacheSize = detectedCache
+		if o.CacheSize == 0 {
+			o.CacheSize = defaultCacheSize
+		}
+	}
+	if o.SegmentSize == 0 {
+		o.SegmentSize = o.CacheSize / 2
+	}
+	// at least one word
+	if o.SegmentSize < bitsPerWord/8 {
+		o.SegmentSize = bitsPerWord / 8
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.GOMAXPROCS(0)
+	}
+	return o
+}
@@ -0,0 +1,73 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package segment
+
+// Presieve patterns.
+//
+// In the bit representation, multiples of a prime p repeat with a period of
+// 2p bits, so multiples of a group of primes repeat with a period of twice
+// their product.  Patterns are precomputed for groups of the smallest
+// primes, then ORed into the sieve a word at a time rather than crossed off
+// a bit at a time.  Sieving proper starts with the next prime after
+// presieveMax.
+
+// presieveMax is the largest prime handled by presieve patterns.
+const presieveMax = 19
+
+// presieveGroups are groups of primes with a pattern for each group.
+// The period of the first is 10010 bits, the second 646 bits.
+var presieveGroups = [][]uint64{{5, 7, 11, 13}, {17, 19}}
+
+type pattern struct {
+	period uint64
+	// bits of one period, followed by 64 more bits repeating the start,
+	// so that any 64 bits of the pattern can be read from two words.
+	bits []uint64
+}
+
+var patterns []pattern
+
+func init() {
+	for _, g := range presieveGroups {
+		period := uint64(2)
+		for _, p := range g {
+			period *= p
+		}
+		bm := make([]uint64, (period+64)/64+1)
+		for b := uint64(0); b < period+64; b++ {
+			n := 5 + density*b - b&1
+			for _, p := range g {
+				if n%p == 0 {
+					bm[b>>log2Int] |= 1 << (b & mask)
+					break
+				}
+			}
+		}
+		patterns = append(patterns, pattern{period, bm})
+	}
+}
+
+// presieve ORs patterns for primes up to presieveMax into words [w0, w1)
+// of isComposite.  The primes themselves, in word 0, are crossed off as
+// well.
+func presieve(isComposite []uint64, w0, w1 uint64) {
+	for _, pat := range patterns {
+		// bit offset into the pattern of the first word
+		o := w0 * bitsPerWord % pat.period
+		for i := w0; i < w1; i++ {
+			j, sh := o>>log2Int, o&mask
+			w := pat.bits[j] >> sh
+			if sh > 0 {
+				w |= pat.bits[j+1] << (bitsPerWord - sh)
+			}
+			isComposite[i] |= w
+			if o += bitsPerWord; o >= pat.period {
+				o -= pat.period
+			}
+		}
+	}
+}
+
+// presievePrimes are the bits of word 0 for the primes 5 through 19.
+const presievePrimes = 1<<6 - 1
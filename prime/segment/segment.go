@@ -6,7 +6,7 @@ package segment
 
 import (
 	"math"
-	"runtime"
+	"math/bits"
 
 	"github.com/soniakeys/integer/prime"
 )
@@ -39,6 +39,11 @@ func New(n uint64) *Sieve {
 	return new(Sieve).Init(n)
 }
 
+// NewOptions is the Sieve constructor with tuning options.
+func NewOptions(n uint64, o Options) *Sieve {
+	return new(Sieve).InitOptions(n, o)
+}
+
 // Iterate iterates over primes betwing min and max inclusive, and calls
 // the visitor function for each prime.
 //
@@ -94,6 +99,53 @@ func (s *Sieve) Iterate(min, max uint64, visitor prime.Visitor) bool {
 	return true
 }
 
+// IterateDown iterates over primes between max and min inclusive, in
+// decreasing order, and calls the visitor function for each prime.
+// It satisfies prime.ReverseIterator.
+//
+// IterateDown returns false if max > sieve size, otherwise it returns true.
+// It returns true even if no primes happen to be between the specified
+// bounds or if the visitor function terminates iteration early.
+func (s *Sieve) IterateDown(max, min uint64, visitor prime.Visitor) bool {
+	if max > s.Lim {
+		return false
+	}
+	if max >= 5 && max >= min {
+		bLo, bHi, ok := bitRange(min, max)
+		if !ok {
+			goto small
+		}
+		// scan words from the top down
+		i := bHi / bitsPerWord
+		w := ^s.isComposite[i] & (2<<(bHi&mask) - 1)
+		for {
+			for w != 0 {
+				top := bitsPerWord - 1 - bits.LeadingZeros64(w)
+				b := i*bitsPerWord + uint64(top)
+				if b < bLo {
+					break
+				}
+				if visitor(5 + density*b - b&1) {
+					return true
+				}
+				w &^= 1 << uint(top)
+			}
+			if i == bLo/bitsPerWord {
+				break
+			}
+			i--
+			w = ^s.isComposite[i]
+		}
+	}
+small:
+	for _, p := range []uint64{3, 2} {
+		if p <= max && p >= min && visitor(p) {
+			return true
+		}
+	}
+	return true
+}
+
 // Prime number sieve, Eratosthenes (276-194 b.c.)
 // Adapted from code by Peter Luschny.  Luschny algorithm implements
 // 2,3 wheel logic, bit representation, and precomputed small primes.
@@ -104,6 +156,17 @@ func (s *Sieve) Iterate(min, max uint64, visitor prime.Visitor) bool {
 // less than or equal to the parameter n.
 // If n <= 0, the passed object is set to the zero object
 func (ps *Sieve) Init(n uint64) *Sieve {
+	return ps.InitOptions(n, Options{})
+}
+
+// InitOptions is Init with tuning options.  Zero valued fields of o take
+// default values.
+func (ps *Sieve) InitOptions(n uint64, o Options) *Sieve {
+	return ps.init(n, o.resolve())
+}
+
+// init is Init with options resolved.
+func (ps *Sieve) init(n uint64, o Options) *Sieve {
 	if n <= 0 {
 		*ps = Sieve{}
 		return ps
@@ -117,30 +180,31 @@ func (ps *Sieve) Init(n uint64) *Sieve {
 
 	ps.isComposite = make([]uint64, (n+wordCap-1)/wordCap)
 
-	// it would be nice to query for L2 cache size.
-	const l2cacheSize = 4e6
-
-	// leave some cache for other purposes.
-	const l2quota = l2cacheSize / 2
-
 	// determine bound for single threaded computation
 	var maxB, wordsq uint64
 	all := uint64(n) / density
-	if n < l2quota*density*8 {
-		// if sieve fits in L2, no need for segmentation,
-		// bound is entire range
+	if n < o.SegmentSize*uint64(o.Workers)*density*8 {
+		// if sieve fits in the segments of all workers, no need for
+		// segmentation, bound is entire range
 		maxB = all
 	} else {
 		// run single threaded only to square root of n
 		wordsq = uint64(math.Ceil(math.Sqrt(float64(len(ps.isComposite)))))
 		maxB = wordsq * bitsPerWord
 	}
+	if wordsq > 0 {
+		presieve(ps.isComposite, 0, wordsq)
+	} else {
+		presieve(ps.isComposite, 0, uint64(len(ps.isComposite)))
+	}
+	ps.isComposite[0] &^= presievePrimes
 
 	var d1, d2, p1, p2, s, s2 uint64 = 8, 8, 3, 7, 7, 3
 	var toggle bool
 
 	for bx := uint64(0); s < maxB; bx++ {
-		if (ps.isComposite[bx>>log2Int] & (1 << (bx & mask))) == 0 {
+		if (ps.isComposite[bx>>log2Int]&(1<<(bx&mask))) == 0 &&
+			p1+p2 > 2*presieveMax {
 			inc := p1 + p2
 			for c := s; c < maxB; c += inc {
 				ps.isComposite[c>>log2Int] |= 1 << (c & mask)
@@ -172,17 +236,69 @@ func (ps *Sieve) Init(n uint64) *Sieve {
 	}
 
 	// parallelize the rest
-	wordsNonSq := uint64(len(ps.isComposite)) - wordsq
-	nCpu := runtime.GOMAXPROCS(0)
-	segQuota := uint64(l2quota / nCpu)
-	bytesToSegment := wordsNonSq * bitsPerWord / 8
-	segments := (bytesToSegment + segQuota - 1) / segQuota
-	wordsPerSegment := (wordsNonSq + segments - 1) / segments
-
-	type segCS struct {
+	ps.sieveSpans(wordsq, o)
+	return ps
+}
+
+// sievingPrime holds a sieving prime p as bit positions in the sieve.
+// Multiples of p not divisible by 2 or 3 lie in two progressions with
+// step inc = 2p, starting at bit positions s and s+s2 of p².
+type sievingPrime struct {
+	s, s2, inc uint64
+}
+
+// bucketEntry is the next multiple c to cross off in one progression of
+// a large sieving prime.
+type bucketEntry struct {
+	c, inc uint64
+}
+
+// sieveSpans completes the sieve from word w0 to the end, with the sieve
+// below w0 already complete up to the square root of the limit.
+//
+// The range is divided into one span per worker, each sieved in segments
+// of o.SegmentSize bytes.  Sieving primes with a step
+// smaller than a segment are crossed off every segment.  Larger primes
+// use the bucket sieve of Oliveira e Silva:  each one is kept in a bucket
+// for the next segment it hits, so that a segment only touches the large
+// primes that actually hit it.
+func (ps *Sieve) sieveSpans(w0 uint64, o Options) {
+	nBits := uint64(len(ps.isComposite)) * bitsPerWord
+	var sp []sievingPrime
+	var d1, d2, p1, p2, s, s2 uint64 = 8, 8, 3, 7, 7, 3
+	var toggle bool
+	for bx := uint64(0); s < nBits; bx++ {
+		if (ps.isComposite[bx>>log2Int]&(1<<(bx&mask))) == 0 &&
+			p1+p2 > 2*presieveMax {
+			sp = append(sp, sievingPrime{s, s2, p1 + p2})
+		}
+		if toggle {
+			toggle = false
+			s += d1
+			d2 += 8
+			p1 += 2
+			p2 += 6
+			s2 = p1
+		} else {
+			toggle = true
+			s += d2
+			d1 += 16
+			p1 += 2
+			p2 += 2
+			s2 = p2
+		}
+	}
+
+	nCpu := o.Workers
+	// whole words, so that segments can be presieved
+	segBits := o.SegmentSize * 8 &^ mask
+	spanWords := (uint64(len(ps.isComposite)) - w0 + uint64(nCpu) - 1) /
+		uint64(nCpu)
+
+	type spanCS struct {
 		start, end uint64
 	}
-	segCh := make(chan *segCS)
+	segCh := make(chan *spanCS)
 	doneCh := make(chan int)
 
 	// dispatcher
@@ -192,70 +308,84 @@ func (ps *Sieve) Init(n uint64) *Sieve {
 			// workers
 			go func() {
 				for {
-					seg := <-segCh
-					if seg == nil {
+					span := <-segCh
+					if span == nil {
 						return
 					}
-					minB := bitsPerWord * seg.start
-					maxB := bitsPerWord * seg.end
-
-					var d1, d2, p1, p2, s, s2 uint64 = 8, 8, 3, 7, 7, 3
-					var toggle bool
-
-					for bx := uint(0); s < maxB; bx++ {
-						if (ps.isComposite[bx>>log2Int] & (1 << (bx & mask))) == 0 {
-							inc := p1 + p2
-
-							c := s
-							if c < minB {
-								c = minB + inc - 1 - (minB-c-1)%inc
-							}
-							for ; c < maxB; c += inc {
-								ps.isComposite[c>>log2Int] |= 1 << (c & mask)
-							}
-							c = s + s2
-							if c < minB {
-								c = minB + inc - 1 - (minB-c-1)%inc
-							}
-							for ; c < maxB; c += inc {
-								ps.isComposite[c>>log2Int] |= 1 << (c & mask)
-							}
-						}
-
-						if toggle {
-							toggle = false
-							s += d1
-							d2 += 8
-							p1 += 2
-							p2 += 6
-							s2 = p1
-						} else {
-							toggle = true
-							s += d2
-							d1 += 16
-							p1 += 2
-							p2 += 2
-							s2 = p2
-						}
-					}
+					ps.sieveSpan(sp, bitsPerWord*span.start,
+						bitsPerWord*span.end, segBits)
 					doneCh <- 0
 				}
 			}()
 		}
 
 		// dispatch
-		start, end := wordsq, wordsq+wordsPerSegment
-		for i := uint64(1); i < segments; i++ {
-			segCh <- &segCS{start, end}
-			start, end = end, end+wordsPerSegment
+		for start := w0; start < uint64(len(ps.isComposite)); start += spanWords {
+			end := start + spanWords
+			if end > uint64(len(ps.isComposite)) {
+				end = uint64(len(ps.isComposite))
+			}
+			segCh <- &spanCS{start, end}
 		}
-		segCh <- &segCS{start, uint64(len(ps.isComposite))}
 	}()
 
 	// count completions
-	for i := uint64(0); i < segments; i++ {
+	for start := w0; start < uint64(len(ps.isComposite)); start += spanWords {
 		<-doneCh
 	}
 	close(segCh)
-	return ps
+}
+
+// sieveSpan crosses off multiples of sieving primes in bit positions
+// [minB, maxB), segment by segment.  minB, maxB and segBits are multiples
+// of the word size.  Each segment is presieved before crossing off.
+func (ps *Sieve) sieveSpan(sp []sievingPrime, minB, maxB, segBits uint64) {
+	// first multiple >= minB
+	first := func(c, inc uint64) uint64 {
+		if c < minB {
+			c = minB + inc - 1 - (minB-c-1)%inc
+		}
+		return c
+	}
+	ic := ps.isComposite
+	nSeg := (maxB - minB + segBits - 1) / segBits
+	var small []bucketEntry
+	buckets := make([][]bucketEntry, nSeg)
+	for _, p := range sp {
+		if p.s >= maxB {
+			break
+		}
+		for _, c := range []uint64{p.s, p.s + p.s2} {
+			e := bucketEntry{first(c, p.inc), p.inc}
+			switch {
+			case p.inc < segBits:
+				small = append(small, e)
+			case e.c < maxB:
+				k := (e.c - minB) / segBits
+				buckets[k] = append(buckets[k], e)
+			}
+		}
+	}
+	for k := uint64(0); k < nSeg; k++ {
+		segEnd := minB + (k+1)*segBits
+		if segEnd > maxB {
+			segEnd = maxB
+		}
+		presieve(ic, (minB+k*segBits)/bitsPerWord, segEnd/bitsPerWord)
+		for i := range small {
+			c, inc := small[i].c, small[i].inc
+			for ; c < segEnd; c += inc {
+				ic[c>>log2Int] |= 1 << (c & mask)
+			}
+			small[i].c = c
+		}
+		for _, e := range buckets[k] {
+			ic[e.c>>log2Int] |= 1 << (e.c & mask)
+			if e.c += e.inc; e.c < maxB {
+				j := (e.c - minB) / segBits
+				buckets[j] = append(buckets[j], e)
+			}
+		}
+		buckets[k] = nil
+	}
 }
@@ -1,9 +1,13 @@
 package segment_test
 
 import (
+	"reflect"
+	"runtime"
 	"testing"
 
+	"github.com/soniakeys/integer/prime"
 	"github.com/soniakeys/integer/prime/segment"
+	"github.com/soniakeys/integer/prime/sieve"
 )
 
 // a few tests on the zero object
@@ -28,6 +32,112 @@ func TestZero(t *testing.T) {
 	}
 }
 
+func TestPrimes(t *testing.T) {
+	s := segment.New(1e7)
+	for _, r := range [][2]uint64{{0, 0}, {0, 4}, {3, 3}, {4, 4}, {0, 100},
+		{5, 5}, {6, 10}, {100, 200}, {1e6 + 1, 1e6 + 2}, {0, 1e7}, {12345, 9876543}} {
+		var want []uint64
+		s.Iterate(r[0], r[1], func(p uint64) (terminate bool) {
+			want = append(want, p)
+			return
+		})
+		got := s.Primes(r[0], r[1])
+		if got == nil || !reflect.DeepEqual(got, append([]uint64{}, want...)) {
+			t.Errorf("Primes(%d, %d): %d primes, want %d",
+				r[0], r[1], len(got), len(want))
+		}
+	}
+	if s.Primes(0, 1e7+1) != nil {
+		t.Error("Primes accepted max > Limit")
+	}
+	if n := len(prime.Primes(s)); n != 664579 {
+		t.Error("prime.Primes", n)
+	}
+}
+
+func TestOptions(t *testing.T) {
+	d := segment.DefaultOptions()
+	if d.CacheSize == 0 || d.SegmentSize == 0 || d.Workers == 0 {
+		t.Fatal("DefaultOptions", d)
+	}
+	want := segment.New(1e7).Primes(0, 1e7)
+	for _, o := range []segment.Options{
+		{SegmentSize: 1 << 12, Workers: 2},
+		{CacheSize: 1 << 16, Workers: 5},
+		{SegmentSize: 1e9},
+	} {
+		if got := segment.NewOptions(1e7, o).Primes(0, 1e7); !reflect.DeepEqual(got, want) {
+			t.Errorf("NewOptions %+v: %d primes, want %d", o, len(got), len(want))
+		}
+	}
+}
+
+func BenchmarkPrimes1e8(b *testing.B) {
+	s := segment.New(1e8)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Primes(0, 1e8)
+	}
+}
+
+func BenchmarkCount1e8(b *testing.B) {
+	s := segment.New(1e8)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		s.Count(0, 1e8)
+	}
+}
+
+func BenchmarkIterate1e8(b *testing.B) {
+	s := segment.New(1e8)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		r := make([]uint64, 0, 5761455)
+		s.Iterate(0, 1e8, func(p uint64) (terminate bool) {
+			r = append(r, p)
+			return
+		})
+	}
+}
+
+// Count is checked with tiny segments, so the sieve has many segment
+// boundaries, and with GOMAXPROCS 4, so ranges are divided into 16 chunks.
+// Ranges are stepped so that their ends fall at varied positions within
+// words and chunks.
+func TestCount(t *testing.T) {
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(4))
+	const n = 1e5
+	s := segment.NewOptions(n, segment.Options{SegmentSize: 8, Workers: 3})
+	// pi[x] is the number of primes <= x, from package sieve
+	pi := make([]uint64, n+1)
+	sieve.New(n).Iterate(0, n, func(p uint64) (terminate bool) {
+		pi[p] = 1
+		return
+	})
+	for x := 1; x <= n; x++ {
+		pi[x] += pi[x-1]
+	}
+	for min := uint64(0); min <= n; min += 1237 {
+		for _, w := range []uint64{0, 1, 190, 191, 192, 6000, 20000, n} {
+			max := min + w
+			if max > n {
+				max = n
+			}
+			want := pi[max]
+			if min > 0 {
+				want -= pi[min-1]
+			}
+			if got := s.Count(min, max); got != want {
+				t.Fatalf("Count(%d, %d) = %d, want %d", min, max, got, want)
+			}
+		}
+	}
+	if s.Count(0, n+1) != 0 {
+		t.Error("Count accepted max > Limit")
+	}
+	var _ prime.Counter = s
+}
+
 func Benchmark1e4(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		segment.New(1e4).Iterate(1, 1e4, func(uint64) (terminate bool) {
@@ -67,3 +177,21 @@ func Benchmark1e8(b *testing.B) {
 		})
 	}
 }
+
+func BenchmarkInit1e6(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		segment.New(1e6)
+	}
+}
+
+func BenchmarkInit1e8(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		segment.New(1e8)
+	}
+}
+
+func BenchmarkInit1e9(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		segment.New(1e9)
+	}
+}
@@ -10,9 +10,10 @@
 package sieve
 
 import (
-	"math"
+	"math/bits"
 
 	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/bounds"
 	"github.com/soniakeys/integer/xmath"
 )
 
@@ -170,18 +171,116 @@ func (ps *Sieve) Iterate(min, max uint64, visitor prime.Visitor) bool {
 	return true
 }
 
+// IterateDown iterates over primes between max and min inclusive, in
+// decreasing order, and calls the visitor function for each prime.
+// It satisfies prime.ReverseIterator.
+//
+// IterateDown returns false if max > sieve size, otherwise it returns true.
+// It returns true even if no primes happen to be between the specified
+// bounds or if the visitor function terminates iteration early.
+func (ps *Sieve) IterateDown(max, min uint64, visitor prime.Visitor) bool {
+	if max > ps.Lim {
+		return false
+	}
+	if max >= 5 && max >= min {
+		bLo, bHi, ok := bitRange(min, max)
+		if !ok {
+			goto small
+		}
+		// scan words from the top down
+		i := bHi / bitsPerInt
+		w := ^ps.isComposite[i] & (2<<(bHi&mask) - 1)
+		for {
+			for w != 0 {
+				top := bitsPerInt - 1 - bits.LeadingZeros32(w)
+				b := i*bitsPerInt + uint64(top)
+				if b < bLo {
+					break
+				}
+				if visitor(5 + 3*b - b&1) {
+					return true
+				}
+				w &^= 1 << uint(top)
+			}
+			if i == bLo/bitsPerInt {
+				break
+			}
+			i--
+			w = ^ps.isComposite[i]
+		}
+	}
+small:
+	for _, p := range []uint64{3, 2} {
+		if p <= max && p >= min && visitor(p) {
+			return true
+		}
+	}
+	return true
+}
+
+// bitRange returns bit positions of the smallest candidate >= min and
+// the largest candidate <= max, for max >= 5.  ok is false if there are no
+// candidates in the range.
+func bitRange(min, max uint64) (bLo, bHi uint64, ok bool) {
+	bHi = max/3 - 1
+	if 5+3*bHi-bHi&1 > max {
+		bHi--
+	}
+	if min > 5 {
+		bLo = min/3 - 1
+		if 5+3*bLo-bLo&1 < min {
+			bLo++
+		}
+	}
+	return bLo, bHi, bLo <= bHi
+}
+
+// Count returns the number of primes between min and max inclusive, or 0
+// if max > Limit().  It satisfies prime.Counter.
+//
+// Words of the sieve are counted with popcount, partial words at the ends
+// of the range being masked.
+func (ps *Sieve) Count(min, max uint64) uint64 {
+	if max > ps.Lim {
+		return 0
+	}
+	var n uint64
+	for _, p := range []uint64{2, 3} {
+		if p >= min && p <= max {
+			n++
+		}
+	}
+	if max < 5 {
+		return n
+	}
+	bLo, bHi, ok := bitRange(min, max)
+	if !ok {
+		return n
+	}
+	iLo, iHi := bLo/bitsPerInt, bHi/bitsPerInt
+	for i := iLo; i <= iHi; i++ {
+		w := ^ps.isComposite[i]
+		if i == iLo {
+			w &^= 1<<(bLo&mask) - 1
+		}
+		if i == iHi {
+			w &= 2<<(bHi&mask) - 1
+		}
+		n += uint64(bits.OnesCount32(w))
+	}
+	return n
+}
+
 // InitPi similar to Init, but parameter is a minimum number of
 // prime numbers to find rather than a maximum value of primes.
 //    
 // Mathematically, π(n), is the prime counting function, the number of primes
-// less than or equal to n.  InitPi selects a bound for n, such that π(n) ≥ pn.
+// less than or equal to n.  InitPi selects a bound for n, such that π(n) ≥ pn,
+// using the proven upper bound for the pn-th prime from package bounds.
 func (ps *Sieve) InitPi(pn uint64) {
-	var n uint64
-	if pn <= smallPiLimit {
-		n = smallCompositeLimit
-	} else {
-		ln := math.Log(float64(pn))
-		n = pn * uint64(ln+math.Log(ln))
+	n := smallCompositeLimit
+	if pn > smallPiLimit {
+		n = bounds.PnUpper(pn)
 	}
 	ps.Init(n)
 }
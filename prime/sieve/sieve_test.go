@@ -3,6 +3,7 @@ package sieve_test
 import (
 	"testing"
 
+	"github.com/soniakeys/integer/prime"
 	"github.com/soniakeys/integer/prime/sieve"
 )
 
@@ -59,6 +60,43 @@ func TestSieve(t *testing.T) {
 	}
 }
 
+func TestInitPi(t *testing.T) {
+	for _, pn := range []uint64{77, 1000, 78498, 78499, 700000} {
+		var s sieve.Sieve
+		s.InitPi(pn)
+		n := uint64(len(prime.Primes(&s)))
+		// enough primes, but not far too many.
+		if n < pn || float64(n) > 1.15*float64(pn) {
+			t.Errorf("InitPi(%d): %d primes", pn, n)
+		}
+	}
+}
+
+func TestCount(t *testing.T) {
+	for _, n := range []uint64{0, 100, 384, 385, 1e6} {
+		s := sieve.New(n)
+		for _, r := range [][2]uint64{{0, n}, {2, 2}, {3, 4}, {4, 4},
+			{5, n}, {n / 3, n / 2}, {n / 2, n}, {n, n}} {
+			if r[1] > n {
+				continue
+			}
+			var want uint64
+			s.Iterate(r[0], r[1], func(uint64) (terminate bool) {
+				want++
+				return
+			})
+			if got := s.Count(r[0], r[1]); got != want {
+				t.Errorf("New(%d).Count(%d, %d) = %d, want %d",
+					n, r[0], r[1], got, want)
+			}
+		}
+		if s.Count(0, n+1) != 0 {
+			t.Error("Count accepted max > Limit")
+		}
+	}
+	var _ prime.Counter = new(sieve.Sieve)
+}
+
 func Benchmark1e4(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		sieve.New(1e4).Iterate(1, 1e4, func(uint64) (terminate bool) {
@@ -0,0 +1,298 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package smooth computes B-smooth numbers, numbers with no prime factor
+// greater than B.
+//
+// 5-smooth numbers are the Hamming numbers, OEIS A051037.
+package smooth
+
+import (
+	"container/heap"
+	"math"
+	"math/big"
+	"sort"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/xmath"
+)
+
+// Smooth type holds the primes up to a smoothness bound B.
+//
+// Smooth iterates over B-smooth numbers rather than primes, but otherwise
+// satisfies prime.Generator.  There is no limit other than the maximum
+// value of a uint64.
+type Smooth struct {
+	B      uint64
+	Primes []uint64
+	prod   *big.Int   // product of Primes, computed as needed by Test.
+	tab    [][]uint32 // table of Ψ, computed as needed by Psi.
+}
+
+// New constructs a Smooth object for smoothness bound b, with primes from g.
+//
+// New returns nil if b > g.Limit().
+func New(b uint64, g prime.Generator) *Smooth {
+	if b > g.Limit() {
+		return nil
+	}
+	return &Smooth{B: b, Primes: prime.Primes(g, 2, b)}
+}
+
+// Limit satisfies prime.Generator.
+func (s *Smooth) Limit() uint64 {
+	return math.MaxUint64
+}
+
+// candidate is a smooth number and the index of its largest prime factor.
+type candidate struct {
+	n  uint64
+	px int
+}
+
+type candHeap []candidate
+
+func (h candHeap) Len() int            { return len(h) }
+func (h candHeap) Less(i, j int) bool  { return h[i].n < h[j].n }
+func (h candHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *candHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *candHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+// Iterate calls the visitor function for each B-smooth number between min
+// and max inclusive, in increasing order.
+//
+// Numbers are generated from a priority queue starting with 1.  Each
+// number n taken from the queue is extended by multiplying by primes p
+// not less than the largest prime factor of n, so each smooth number
+// enters the queue exactly once.  This generates all Ψ(max, B) smooth
+// numbers up to max, so if there are more smooth numbers below min than
+// numbers between min and max, the interval is sieved instead.
+func (s *Smooth) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if min < 1 {
+		min = 1
+	}
+	if min > max {
+		return true
+	}
+	if w := max - min + 1; w != 0 && min > w && s.Psi(min-1) > w {
+		s.sieve(min, max, visitor)
+		return true
+	}
+	h := &candHeap{{1, 0}}
+	for h.Len() > 0 {
+		c := heap.Pop(h).(candidate)
+		if c.n >= min && visitor(c.n) {
+			return true
+		}
+		for i := c.px; i < len(s.Primes); i++ {
+			p := s.Primes[i]
+			if c.n > max/p {
+				break
+			}
+			heap.Push(h, candidate{c.n * p, i})
+		}
+	}
+	return true
+}
+
+// blockSize is the number of integers sieved at a time.
+const blockSize = 1 << 15
+
+// sieve visits smooth numbers in [min, max], min >= 1, by sieving in
+// blocks.  Each prime power p^e dividing n is divided out, and n is smooth
+// if the cofactor is 1.
+func (s *Smooth) sieve(min, max uint64, visitor prime.Visitor) {
+	var rem [blockSize]uint64
+	for b0 := min; ; b0 += blockSize {
+		nb := max - b0 + 1
+		if nb > blockSize || nb == 0 {
+			nb = blockSize
+		}
+		for j := uint64(0); j < nb; j++ {
+			rem[j] = b0 + j
+		}
+		bEnd := b0 + nb - 1
+		for _, p := range s.Primes {
+			if p > bEnd {
+				break
+			}
+			for pe := p; ; pe *= p {
+				for j := (pe - b0%pe) % pe; j < nb; j += pe {
+					rem[j] /= p
+				}
+				if pe > bEnd/p {
+					break
+				}
+			}
+		}
+		for j := uint64(0); j < nb; j++ {
+			if rem[j] == 1 && visitor(b0+j) {
+				return
+			}
+		}
+		if max-b0 < blockSize {
+			return
+		}
+	}
+}
+
+// Count returns the number of B-smooth numbers between min and max
+// inclusive.  See Psi.
+func (s *Smooth) Count(min, max uint64) uint64 {
+	if min < 1 {
+		min = 1
+	}
+	if min > max {
+		return 0
+	}
+	return s.Psi(max) - s.Psi(min-1)
+}
+
+// Psi returns Ψ(x, B), the number of B-smooth numbers <= x.
+//
+// It uses the recurrence Ψ(x, p_k) = Ψ(x, p_(k-1)) + Ψ(x/p_k, p_k),
+// with Ψ(x, p_k) = x when p_k >= x.  Primes p_i > √x divide a smooth
+// number at most once and with a cofactor less than p_i, so
+// Ψ(x, p_k) = Ψ(x, p_j) + Σ ⌊x/p_i⌋ for j < i <= k, where p_j is the
+// largest prime <= √x.  The sum is taken over runs of primes with equal
+// quotients.  For x < psiTab, Ψ is looked up in a table, computed on
+// first use and kept with s.
+func (s *Smooth) Psi(x uint64) uint64 {
+	if s.tab == nil {
+		s.tab = psiTable(s.Primes)
+	}
+	return psi(s.Primes, s.tab, x, len(s.Primes)-1)
+}
+
+// psiTab bounds x for tabulated values of Ψ(x, p_k).
+const psiTab = 1 << 16
+
+// psiTable returns tab with tab[k][x] = Ψ(x, ps[k]) for x < psiTab and
+// ps[k]² < psiTab.  Larger primes are handled by the cutoff at √x.  There
+// are at most 54 rows, about 14MB.
+func psiTable(ps []uint64) [][]uint32 {
+	var tab [][]uint32
+	for k, p := range ps {
+		if p*p >= psiTab {
+			break
+		}
+		t := make([]uint32, psiTab)
+		for x := range t {
+			if k == 0 {
+				if x > 0 {
+					t[x] = uint32(xmath.Log2(uint(x))) + 1
+				}
+			} else {
+				t[x] = tab[k-1][x] + t[uint64(x)/p]
+			}
+		}
+		tab = append(tab, t)
+	}
+	return tab
+}
+
+// psi returns Ψ(x, ps[k]).
+func psi(ps []uint64, tab [][]uint32, x uint64, k int) uint64 {
+	switch {
+	case x == 0:
+		return 0
+	case k < 0:
+		return 1 // just 1
+	case ps[k] >= x:
+		return x
+	}
+	p := ps[k]
+	if p <= x/p {
+		if x < psiTab {
+			return uint64(tab[k][x])
+		}
+		return psi(ps, tab, x, k-1) + psi(ps, tab, x/p, k)
+	}
+	// p_j is the largest prime <= √x.
+	j := sort.Search(k, func(i int) bool { return ps[i] > x/ps[i] }) - 1
+	n := psi(ps, tab, x, j)
+	for i := k; i > j; {
+		q := x / ps[i]
+		if ps[i] < 64*q {
+			// runs are short, sum term by term.
+			n += q
+			i--
+			continue
+		}
+		// primes in (x/(q+1), x/q] have quotient q, about p/(q·ln p)
+		// of them.
+		lo := sort.Search(i, func(i int) bool { return ps[i] > x/(q+1) })
+		if lo <= j {
+			lo = j + 1
+		}
+		n += q * uint64(i-lo+1)
+		i = lo - 1
+	}
+	return n
+}
+
+// Test tests each of xs for B-smoothness, returning a slice with true for
+// each x that is B-smooth.
+//
+// It uses Bernstein's batch algorithm.  A product tree of xs is built,
+// then the product P of primes up to B is reduced mod each x through a
+// remainder tree.  x is smooth if (P mod x)^(2^e) ≡ 0 (mod x), where
+// 2^e is at least the bit length of x.
+//
+// Values less than 1 are not smooth.
+func (s *Smooth) Test(xs []*big.Int) []bool {
+	r := make([]bool, len(xs))
+	if len(xs) == 0 {
+		return r
+	}
+	if s.prod == nil {
+		s.prod = xmath.Product(new(big.Int), s.Primes)
+	}
+	// leaves are xs, with values < 1 replaced by 1.
+	one := big.NewInt(1)
+	level := make([]*big.Int, len(xs))
+	for i, x := range xs {
+		if x.Sign() > 0 {
+			level[i] = x
+		} else {
+			level[i] = one
+		}
+	}
+	tree := [][]*big.Int{level}
+	for len(level) > 1 {
+		next := make([]*big.Int, (len(level)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(level) {
+				next[i] = new(big.Int).Mul(level[2*i], level[2*i+1])
+			} else {
+				next[i] = level[2*i]
+			}
+		}
+		tree = append(tree, next)
+		level = next
+	}
+	rem := []*big.Int{new(big.Int).Mod(s.prod, level[0])}
+	for l := len(tree) - 2; l >= 0; l-- {
+		next := make([]*big.Int, len(tree[l]))
+		for i, m := range tree[l] {
+			next[i] = new(big.Int).Mod(rem[i/2], m)
+		}
+		rem = next
+	}
+	for i, x := range xs {
+		if x.Sign() <= 0 {
+			continue
+		}
+		y := rem[i]
+		for b := 1; b < x.BitLen(); b *= 2 {
+			y.Mod(y.Mul(y, y), x)
+		}
+		r[i] = y.Sign() == 0
+	}
+	return r
+}
@@ -0,0 +1,125 @@
+package smooth_test
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/smooth"
+)
+
+// OEIS A051037, Hamming numbers.
+var hamming = []uint64{1, 2, 3, 4, 5, 6, 8, 9, 10, 12, 15, 16, 18, 20, 24,
+	25, 27, 30, 32, 36, 40, 45, 48, 50, 54, 60, 64, 72, 75, 80, 81, 90}
+
+func TestIterate(t *testing.T) {
+	var got []uint64
+	smooth.New(5, sieve.New(5)).Iterate(0, 90, func(n uint64) bool {
+		got = append(got, n)
+		return false
+	})
+	if len(got) != len(hamming) {
+		t.Fatalf("Hamming numbers: %v", got)
+	}
+	for i, n := range hamming {
+		if got[i] != n {
+			t.Fatalf("Hamming numbers: %v", got)
+		}
+	}
+}
+
+// bruteSmooth returns true if n has no prime factor > b.
+func bruteSmooth(n, b uint64) bool {
+	for d := uint64(2); d*d <= n; d++ {
+		for n%d == 0 {
+			if d > b {
+				return false
+			}
+			n /= d
+		}
+	}
+	return n <= b
+}
+
+func TestCount(t *testing.T) {
+	s := sieve.New(1000)
+	// OEIS A051037: there are 507 Hamming numbers <= 1e6.
+	if n := smooth.New(5, s).Count(1, 1e6); n != 507 {
+		t.Errorf("Hamming numbers <= 1e6: %d, want 507", n)
+	}
+	for _, b := range []uint64{2, 7, 30, 100} {
+		sm := smooth.New(b, s)
+		for _, w := range []struct{ min, max uint64 }{
+			{0, 5000}, {1000, 3000}, {4999, 5000}, {1e6, 1e6 + 5000},
+		} {
+			var want uint64
+			for n := w.min; n <= w.max; n++ {
+				if n > 0 && bruteSmooth(n, b) {
+					want++
+				}
+			}
+			var it uint64
+			sm.Iterate(w.min, w.max, func(uint64) bool {
+				it++
+				return false
+			})
+			if c := sm.Count(w.min, w.max); c != want || it != want {
+				t.Errorf("%d-smooth in (%d, %d): Count %d, Iterate %d, want %d",
+					b, w.min, w.max, c, it, want)
+			}
+		}
+	}
+}
+
+// Count against Iterate above the table of Ψ.
+func TestCountLarge(t *testing.T) {
+	s := sieve.New(1000)
+	for _, tc := range []struct{ b, min, max uint64 }{
+		{3, 0, 3e6}, {100, 0, 3e6}, {1000, 0, 3e6},
+		{3, 65535, 65537}, {1000, 65535, 65537},
+		{3, 1e6, 2e6}, {1000, 1e6, 2e6},
+		// sieved, except for b = 3
+		{3, 1e9, 1e9 + 1e5}, {100, 1e9, 1e9 + 1e5}, {1000, 1e9, 1e9 + 1e5},
+		{30, math.MaxUint64 - 1e5, math.MaxUint64},
+	} {
+		sm := smooth.New(tc.b, s)
+		var it uint64
+		sm.Iterate(tc.min, tc.max, func(uint64) bool {
+			it++
+			return false
+		})
+		if c := sm.Count(tc.min, tc.max); c != it {
+			t.Errorf("%d-smooth in (%d, %d): Count %d, Iterate %d",
+				tc.b, tc.min, tc.max, c, it)
+		}
+	}
+}
+
+func TestBatch(t *testing.T) {
+	const b = 50
+	sm := smooth.New(b, sieve.New(b))
+	var xs []*big.Int
+	var want []bool
+	for n := int64(-2); n < 3000; n += 7 {
+		xs = append(xs, big.NewInt(n))
+		want = append(want, n > 0 && bruteSmooth(uint64(n), b))
+	}
+	// a large smooth number, and a large one that is not.
+	x := new(big.Int).Exp(big.NewInt(47), big.NewInt(80), nil)
+	xs = append(xs, x, new(big.Int).Mul(x, big.NewInt(53)))
+	want = append(want, true, false)
+	got := sm.Test(xs)
+	for i, w := range want {
+		if got[i] != w {
+			t.Errorf("Test(%d) = %t, want %t", xs[i], got[i], w)
+		}
+	}
+}
+
+func BenchmarkPsi(b *testing.B) {
+	sm := smooth.New(1000, sieve.New(1000))
+	for i := 0; i < b.N; i++ {
+		sm.Psi(1e12)
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+package sprp
+
+import (
+	"context"
+	"runtime"
+)
+
+// batchSize is the number of candidates a TestAll worker takes at a time.
+const batchSize = 1024
+
+// TestAll tests each of a slice of candidates for primality.
+//
+// Candidates are tested in parallel, by runtime.GOMAXPROCS workers each
+// with its own SPRP object.  The result is a slice of the same length
+// as candidates, where element i is true if candidates[i] is prime.
+//
+// TestAll returns nil if ctx is done before all candidates are tested.
+func TestAll(ctx context.Context, candidates []uint64) []bool {
+	r := make([]bool, len(candidates))
+	nBatch := (len(candidates) + batchSize - 1) / batchSize
+	if nBatch == 0 {
+		return r
+	}
+
+	type batch struct {
+		start, end int
+	}
+	batchCh := make(chan *batch)
+	// buffered so that workers never block on completion, even if
+	// TestAll has returned early.
+	doneCh := make(chan int, nBatch)
+
+	// dispatcher
+	go func() {
+		// start the workers
+		nCpu := runtime.GOMAXPROCS(0)
+		for i := 0; i < nCpu; i++ {
+			// workers
+			go func() {
+				m := New()
+				for {
+					b := <-batchCh
+					if b == nil {
+						return
+					}
+					for i, n := range candidates[b.start:b.end] {
+						r[b.start+i] = m.Prime64(n)
+					}
+					doneCh <- 0
+				}
+			}()
+		}
+
+		// dispatch
+		for start := 0; start < len(candidates); start += batchSize {
+			end := start + batchSize
+			if end > len(candidates) {
+				end = len(candidates)
+			}
+			select {
+			case batchCh <- &batch{start, end}:
+			case <-ctx.Done():
+				close(batchCh)
+				return
+			}
+		}
+		close(batchCh)
+	}()
+
+	// count completions
+	for i := 0; i < nBatch; i++ {
+		select {
+		case <-doneCh:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+	return r
+}
+
+// Result holds the result of testing a single candidate.
+type Result struct {
+	N     uint64
+	Prime bool
+}
+
+// TestStream tests candidates read from a channel for primality.
+//
+// Candidates are tested in parallel, by runtime.GOMAXPROCS workers each
+// with its own SPRP object.  Results are sent on the returned channel as
+// they are found, and so are not necessarily in the order of candidates.
+//
+// The returned channel is closed after the candidates channel is closed
+// and all candidates are tested, or when ctx is done.
+func TestStream(ctx context.Context, candidates <-chan uint64) <-chan Result {
+	r := make(chan Result)
+	nCpu := runtime.GOMAXPROCS(0)
+	doneCh := make(chan int)
+	for i := 0; i < nCpu; i++ {
+		// workers
+		go func() {
+			defer func() { doneCh <- 0 }()
+			m := New()
+			for {
+				var n uint64
+				var ok bool
+				select {
+				case n, ok = <-candidates:
+					if !ok {
+						return
+					}
+				case <-ctx.Done():
+					return
+				}
+				select {
+				case r <- Result{n, m.Prime64(n)}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	// close result channel when all workers are done
+	go func() {
+		for i := 0; i < nCpu; i++ {
+			<-doneCh
+		}
+		close(r)
+	}()
+	return r
+}
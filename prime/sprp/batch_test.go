@@ -0,0 +1,79 @@
+package sprp_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/sprp"
+)
+
+// Compare TestAll and TestStream to a sieve, and compare Prime64 to
+// a few known primes and composites above 2^32.
+func TestBatch(t *testing.T) {
+	const limit = 1e5
+	s := sieve.New(limit)
+	isPrime := make([]bool, limit+1)
+	s.Iterate(0, limit, func(p uint64) (terminate bool) {
+		isPrime[p] = true
+		return
+	})
+	c := make([]uint64, limit+1)
+	for i := range c {
+		c[i] = uint64(i)
+	}
+	r := sprp.TestAll(context.Background(), c)
+	for n, p := range r {
+		if p != isPrime[n] {
+			t.Fatalf("TestAll: %d prime = %t, want %t", n, p, isPrime[n])
+		}
+	}
+
+	ch := make(chan uint64)
+	go func() {
+		for _, n := range c {
+			ch <- n
+		}
+		close(ch)
+	}()
+	var nr int
+	for res := range sprp.TestStream(context.Background(), ch) {
+		if res.Prime != isPrime[res.N] {
+			t.Fatalf("TestStream: %d prime = %t, want %t",
+				res.N, res.Prime, isPrime[res.N])
+		}
+		nr++
+	}
+	if nr != len(c) {
+		t.Fatalf("TestStream returned %d results, want %d", nr, len(c))
+	}
+
+	m := sprp.New()
+	for _, tc := range []struct {
+		n     uint64
+		prime bool
+	}{
+		{1<<32 + 15, true},
+		{1<<61 - 1, true},
+		{1<<64 - 59, true},
+		{1<<64 - 1, false},
+		{4759123141, false},          // strong pseudoprime to 2, 7, 61
+		{3825123056546413051, false}, // strong pseudoprime to bases ≤ 23
+		{4294967291 * 4294967279, false},
+	} {
+		if p := m.Prime64(tc.n); p != tc.prime {
+			t.Errorf("Prime64(%d) = %t, want %t", tc.n, p, tc.prime)
+		}
+	}
+}
+
+func TestBatchCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if r := sprp.TestAll(ctx, make([]uint64, 1e5)); r != nil {
+		t.Error("TestAll on cancelled context returned non-nil")
+	}
+	for range sprp.TestStream(ctx, make(chan uint64)) {
+		t.Error("TestStream on cancelled context returned result")
+	}
+}
@@ -8,10 +8,19 @@
 // In comparison to sieve and priority queue algorithms, SPRP has no up-front
 // compute overhead and no space requirements, but will be ulitimately slower
 // than other algorithms if a large number of primes is requested.
+//
+// Iterate does not test every odd number.  Candidates are first filtered
+// by a 2,3,5,7 wheel and a presieve of small primes over blocks of odd
+// numbers, so that the SPRP test is run only on numbers with no small factor.
+// This makes Iterate reasonably efficient for short windows high in the
+// range, where a sieve would have to be built all the way up.
 package sprp
 
 import (
+	"math/bits"
+
 	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
 	"github.com/soniakeys/integer/xmath"
 )
 
@@ -67,6 +76,42 @@ func (m *SPRP) Limit() uint64 {
 	return 1<<32 - 1
 }
 
+// Presieve constants.
+//
+// Blocks represent odd numbers n = 2k+1 by bit k, blockWords words at a time.
+// Blocks always start on a word boundary so that the wheel pattern can
+// be copied a word at a time.
+const (
+	blockWords   = 512
+	wheelWords   = 3 * 5 * 7 // period of wheel pattern, in words
+	presieveMax  = 1 << 12   // sieve blocks with primes up to this limit
+	firstSieving = 11        // first prime not handled by the wheel
+)
+
+// wheel holds the odd multiples of 3, 5, and 7 as a bit pattern.
+// k%3 == 1 for example, where n = 2k+1 is an odd multiple of 3.
+// 64 bits per word times 105 words is a multiple of 3, 5, and 7 bits so
+// the pattern repeats cleanly every 105 words.
+var wheel [wheelWords]uint64
+
+// presieve holds primes from firstSieving through presieveMax.
+var presieve []uint64
+
+// presieveSq is the square of the largest presieve prime.
+// Survivors of the presieve less than this are prime without further test.
+var presieveSq uint64
+
+func init() {
+	for k := uint64(0); k < wheelWords*64; k++ {
+		if k%3 == 1 || k%5 == 2 || k%7 == 3 {
+			wheel[k>>6] |= 1 << (k & 63)
+		}
+	}
+	presieve = prime.Primes(sieve.New(presieveMax), firstSieving, presieveMax)
+	p := presieve[len(presieve)-1]
+	presieveSq = p * p
+}
+
 // Iterate satisfies prime.Generator.
 func (m *SPRP) Iterate(min, max uint64, visitor prime.Visitor) bool {
 	switch {
@@ -80,13 +125,75 @@ func (m *SPRP) Iterate(min, max uint64, visitor prime.Visitor) bool {
 			return true
 		}
 	}
-	c := uint32(min | 1)
-	m.resetLimit(c)
-	for max32 := uint32(max); c <= max32; c += 2 {
-		if m.Prime(c) && visitor(uint64(c)) {
+	// the wheel primes themselves are crossed off by the wheel pattern.
+	for _, p := range []uint64{3, 5, 7} {
+		if p > max {
+			return true
+		}
+		if p >= min && visitor(p) {
 			return true
 		}
 	}
+	if min < firstSieving {
+		min = firstSieving
+	}
+	if min > max {
+		return true
+	}
+	m.resetLimit(uint32(min))
+
+	var block [blockWords]uint64
+	kMax := (max - 1) / 2
+	for k0 := min / 2 &^ 63; k0 <= kMax; k0 += blockWords * 64 {
+		// size block to cover no more than the remaining range
+		nw := (kMax-k0)/64 + 1
+		if nw > blockWords {
+			nw = blockWords
+		}
+		b := block[:nw]
+		kEnd := k0 + nw*64
+		w := k0 / 64 % wheelWords
+		for i := range b {
+			b[i] = wheel[w]
+			if w++; w == wheelWords {
+				w = 0
+			}
+		}
+		nEnd := 2*kEnd + 1
+		for _, p := range presieve {
+			// start with p*p or the first odd multiple of p in the block
+			c := p * p
+			if c >= nEnd {
+				break
+			}
+			if n0 := 2*k0 + 1; c < n0 {
+				c = (n0 + p - 1) / p * p
+				if c&1 == 0 {
+					c += p
+				}
+			}
+			for j := (c-1)/2 - k0; j < nw*64; j += p {
+				b[j>>6] |= 1 << (j & 63)
+			}
+		}
+		// test survivors
+		for i, bits := range b {
+			for s := ^bits; s != 0; s &= s - 1 {
+				n := 2*(k0+uint64(i)*64+uint64(xmath.TrailingZeros64(s))) + 1
+				switch {
+				case n < min:
+					continue
+				case n > max:
+					return true
+				case n >= presieveSq && !m.Prime(uint32(n)):
+					continue
+				}
+				if visitor(n) {
+					return true
+				}
+			}
+		}
+	}
 	return true
 }
 
@@ -105,9 +212,13 @@ func (m *SPRP) Prime(n uint32) bool {
 	nm1 := n - 1
 	s := xmath.TrailingZeros32(nm1)
 	d := nm1 >> s
-	for i, _ := range m.bases {
-		// compute x := a^d % n
+	for i, a := range m.bases {
 		n64 := uint64(n)
+		if uint64(a)%n64 == 0 {
+			// n divides the base, which tells nothing.
+			continue
+		}
+		// compute x := a^d % n
 		x := uint64(1)
 		p2 := m.basePow2[i][0:]
 		bit := 0
@@ -140,3 +251,79 @@ func (m *SPRP) Prime(n uint32) bool {
 	}
 	return true
 }
+
+// trialPrimes are tried as divisors by Prime64 before any SPRP test.
+var trialPrimes = []uint64{3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// bases64 is a deterministic base set for all n < 2^64.
+// reference: http://miller-rabin.appspot.com/
+var bases64 = []uint64{2, 325, 9375, 28178, 450775, 9780504, 1795265022}
+
+// Prime64 returns true if n is prime.
+//
+// Unlike Prime, any n is accepted.  Small and even n are handled
+// directly, n < 2^32 is tested with Prime, and larger n are tested with
+// a base set valid for the full uint64 range.
+func (m *SPRP) Prime64(n uint64) bool {
+	switch {
+	case n < 2:
+		return false
+	case n < 4:
+		return true
+	case n&1 == 0:
+		return false
+	}
+	for _, p := range trialPrimes {
+		if n%p == 0 {
+			return n == p
+		}
+	}
+	if n <= 1<<32-1 {
+		return m.Prime(uint32(n))
+	}
+	nm1 := n - 1
+	s := bits.TrailingZeros64(nm1)
+	d := nm1 >> uint(s)
+	for _, a := range bases64 {
+		a %= n
+		if a == 0 {
+			continue
+		}
+		x := powMod(a, d, n)
+		if x == 1 || x == nm1 {
+			continue
+		}
+		for r := 1; ; r++ {
+			if r == s {
+				return false
+			}
+			x = mulMod(x, x, n)
+			if x == 1 {
+				return false
+			}
+			if x == nm1 {
+				break
+			}
+		}
+	}
+	return true
+}
+
+// mulMod returns a*b % n without overflow.
+func mulMod(a, b, n uint64) uint64 {
+	hi, lo := bits.Mul64(a, b)
+	_, r := bits.Div64(hi%n, lo, n)
+	return r
+}
+
+// powMod returns a^d % n.
+func powMod(a, d, n uint64) uint64 {
+	x := uint64(1)
+	for ; d > 0; d >>= 1 {
+		if d&1 != 0 {
+			x = mulMod(x, a, n)
+		}
+		a = mulMod(a, a, n)
+	}
+	return x
+}
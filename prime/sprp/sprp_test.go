@@ -3,6 +3,8 @@ package sprp_test
 import (
 	"testing"
 
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
 	"github.com/soniakeys/integer/prime/sprp"
 )
 
@@ -31,6 +33,65 @@ func TestBoundaryCases(t *testing.T) {
 	}
 }
 
+// 18661 is a factor of one of the bases for n < 316349281.
+func TestBaseFactor(t *testing.T) {
+	if !sprp.New().Prime(18661) {
+		t.Error("Prime(18661) = false, want true")
+	}
+}
+
+// Iterate presieves candidates before testing.  Check that it still agrees
+// with the sieve, across block boundaries and on windows starting at
+// assorted alignments.
+func TestPresieve(t *testing.T) {
+	const limit = 3e5
+	ref := prime.Primes(sieve.New(limit), 0, limit)
+	s := sprp.New()
+	for _, w := range []struct{ min, max uint64 }{
+		{0, limit},
+		{9, 131},
+		{4095, 4099},
+		{16750000, 16760000}, // straddles presieve square
+		{65535, 65535 + 1<<16},
+	} {
+		var want []uint64
+		for _, p := range ref {
+			if p >= w.min && p <= w.max {
+				want = append(want, p)
+			}
+		}
+		if w.max > limit {
+			want = nil
+			for n := w.min | 1; n <= w.max; n += 2 {
+				if s.Prime(uint32(n)) {
+					want = append(want, n)
+				}
+			}
+		}
+		got := prime.Primes(s, w.min, w.max)
+		if len(got) != len(want) {
+			t.Errorf("Iterate(%d, %d) found %d primes, want %d",
+				w.min, w.max, len(got), len(want))
+			continue
+		}
+		for i, p := range want {
+			if got[i] != p {
+				t.Errorf("Iterate(%d, %d) prime %d = %d, want %d",
+					w.min, w.max, i, got[i], p)
+				break
+			}
+		}
+	}
+}
+
+func BenchmarkWindowTop(b *testing.B) {
+	for i := 0; i < b.N; i++ {
+		sprp.New().Iterate(1<<32-1e5, 1<<32-1, func(uint64) (terminate bool) {
+			return
+		})
+	}
+}
+
 func Benchmark1e4(b *testing.B) {
 	for i := 0; i < b.N; i++ {
 		sprp.New().Iterate(1, 1e4, func(uint64) (terminate bool) {
@@ -0,0 +1,259 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package tuple enumerates prime k-tuples, or prime constellations.
+//
+// A pattern is a list of offsets, starting with 0, such as {0, 2} for twin
+// primes or {0, 2, 6, 8} for prime quadruplets.  A match is a prime p such
+// that p+o is prime for every offset o of the pattern.
+//
+// Matches are found by sieving.  Only numbers n in the residue classes mod
+// 210 that the pattern allows are represented, n = r + 210i by bit i of a
+// bitmap for each class r.  For each sieving prime p and each offset o, the
+// indexes i where p divides n+o are crossed off, so the survivors are
+// exactly the matches.  Sieving primes are taken from any prime.Generator.
+package tuple
+
+import (
+	"math"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/xmath"
+)
+
+// Some common patterns.
+var (
+	Twin       = []uint64{0, 2}
+	Cousin     = []uint64{0, 4}
+	Sexy       = []uint64{0, 6}
+	TripletA   = []uint64{0, 2, 6}
+	TripletB   = []uint64{0, 4, 6}
+	Quadruplet = []uint64{0, 2, 6, 8}
+)
+
+// wheelPrimes are the primes of the wheel modulus.
+var wheelPrimes = []uint64{2, 3, 5, 7}
+
+const wheelMod = 2 * 3 * 5 * 7
+
+// Tuple type holds a pattern and an underlying generator.
+//
+// Tuple satisfies prime.Generator, generating the first prime of
+// each match.
+type Tuple struct {
+	Gen     prime.Generator
+	Pattern []uint64
+	last    uint64   // last offset of pattern
+	classes []uint64 // residues mod 210 allowed by pattern, increasing
+}
+
+// Admissible returns true if pattern is an admissible pattern.
+//
+// The offsets must start with 0 and be strictly increasing.  Then the
+// pattern is admissible if, for every prime q, the offsets leave at least
+// one residue mod q uncovered.  Only q up to the length of the pattern need
+// be checked.
+func Admissible(pattern []uint64) bool {
+	if len(pattern) == 0 || pattern[0] != 0 {
+		return false
+	}
+	for i := 1; i < len(pattern); i++ {
+		if pattern[i] <= pattern[i-1] {
+			return false
+		}
+	}
+	k := uint64(len(pattern))
+	for q := uint64(2); q <= k; q++ {
+		if !isSmallPrime(q) {
+			continue
+		}
+		covered := make([]bool, q)
+		nc := uint64(0)
+		for _, o := range pattern {
+			if r := o % q; !covered[r] {
+				covered[r] = true
+				nc++
+			}
+		}
+		if nc == q {
+			return false
+		}
+	}
+	return true
+}
+
+func isSmallPrime(q uint64) bool {
+	for d := uint64(2); d*d <= q; d++ {
+		if q%d == 0 {
+			return false
+		}
+	}
+	return q >= 2
+}
+
+// New constructs a Tuple object for pattern with generator g.
+//
+// New returns nil if pattern is not admissible.
+func New(g prime.Generator, pattern ...uint64) *Tuple {
+	if !Admissible(pattern) {
+		return nil
+	}
+	t := &Tuple{
+		Gen:     g,
+		Pattern: pattern,
+		last:    pattern[len(pattern)-1],
+	}
+cl:
+	for r := uint64(0); r < wheelMod; r++ {
+		for _, o := range pattern {
+			for _, q := range wheelPrimes {
+				if (r+o)%q == 0 {
+					continue cl
+				}
+			}
+		}
+		t.classes = append(t.classes, r)
+	}
+	return t
+}
+
+// Limit satisfies prime.Generator.
+//
+// Sieving requires primes up to the square root of the last number of a
+// match, so the limit is based on the square of the limit of the
+// underlying generator, less the last offset of the pattern.  It is at
+// most math.MaxInt64.
+func (t *Tuple) Limit() uint64 {
+	l := uint64(math.MaxInt64)
+	if gl := t.Gen.Limit(); gl < 1<<31 {
+		l = gl*gl + 2*gl // the largest value < (gl+1)^2
+	}
+	if l > t.last {
+		return l - t.last
+	}
+	return 0
+}
+
+// blockBits is the number of indexes i sieved at a time, for each class.
+const blockBits = 1 << 15
+
+// Iterate satisfies prime.Generator.
+//
+// The visitor is called with the first prime p of each match,
+// for p between min and max inclusive.
+func (t *Tuple) Iterate(min, max uint64, visitor prime.Visitor) (ok bool) {
+	if max > t.Limit() {
+		return false
+	}
+	// matches starting with a wheel prime are outside the residue
+	// classes, and are checked directly.
+	for _, p := range wheelPrimes {
+		if p >= min && p <= max && t.smallMatch(p) && visitor(p) {
+			return true
+		}
+	}
+	if min < 11 {
+		min = 11
+	}
+	if min > max || len(t.classes) == 0 {
+		return true
+	}
+	sp := prime.Primes(t.Gen, 11, xmath.FloorSqrt64(max+t.last))
+	// inverse of wheelMod mod each sieving prime
+	inv := make([]uint64, len(sp))
+	for k, p := range sp {
+		inv[k] = inverse(wheelMod%p, p)
+	}
+	iLo, iHi := min/wheelMod, max/wheelMod
+	bm := make([][]uint64, len(t.classes))
+	for c := range bm {
+		bm[c] = make([]uint64, blockBits/64)
+	}
+	for b0 := iLo; b0 <= iHi; b0 += blockBits {
+		nb := iHi - b0 + 1
+		if nb > blockBits {
+			nb = blockBits
+		}
+		// sieve the block for each class and offset
+		for c, r := range t.classes {
+			b := bm[c]
+			for w := range b {
+				b[w] = 0
+			}
+			for _, o := range t.Pattern {
+				ro := r + o
+				for k, p := range sp {
+					// p divides ro + i*wheelMod when i ≡ ti (mod p)
+					ti := (p - ro%p) % p * inv[k] % p
+					// start at p*p or the block, whichever is larger
+					i0 := b0
+					if pp := p * p; pp > ro {
+						if iP := (pp - ro + wheelMod - 1) / wheelMod; iP > i0 {
+							i0 = iP
+						}
+					}
+					for j := i0 + (ti+p-i0%p)%p - b0; j < nb; j += p {
+						b[j>>6] |= 1 << (j & 63)
+					}
+				}
+			}
+		}
+		// visit survivors in increasing order
+		for j := uint64(0); j < nb; j++ {
+			for c, r := range t.classes {
+				if bm[c][j>>6]&(1<<(j&63)) != 0 {
+					continue
+				}
+				n := r + (b0+j)*wheelMod
+				switch {
+				case n < min:
+					continue
+				case n > max:
+					return true
+				}
+				if visitor(n) {
+					return true
+				}
+			}
+		}
+	}
+	return true
+}
+
+// smallMatch returns true if p and p+o for each offset o are prime,
+// by trial division.
+func (t *Tuple) smallMatch(p uint64) bool {
+	for _, o := range t.Pattern {
+		if !isSmallPrime(p + o) {
+			return false
+		}
+	}
+	return true
+}
+
+// inverse returns the inverse of a mod m, for a and m coprime.
+func inverse(a, m uint64) uint64 {
+	var x0, x1 int64 = 0, 1
+	b, mm := int64(m), int64(a)
+	for mm != 0 {
+		q := b / mm
+		b, mm = mm, b-q*mm
+		x0, x1 = x1, x0-q*x1
+	}
+	if x0 < 0 {
+		x0 += int64(m)
+	}
+	return uint64(x0)
+}
+
+// Count returns the number of matches with first prime between min and
+// max inclusive.
+//
+// The ok result is false if max > Limit().
+func (t *Tuple) Count(min, max uint64) (n uint64, ok bool) {
+	ok = t.Iterate(min, max, func(uint64) (terminate bool) {
+		n++
+		return
+	})
+	return
+}
@@ -0,0 +1,117 @@
+package tuple_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/queue"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/tuple"
+	"github.com/soniakeys/integer/prime/wheel"
+)
+
+func TestAdmissible(t *testing.T) {
+	for _, tc := range []struct {
+		pattern []uint64
+		ok      bool
+	}{
+		{tuple.Twin, true},
+		{tuple.Quadruplet, true},
+		{[]uint64{0, 2, 4}, false},
+		{[]uint64{0, 1}, false},
+		{[]uint64{0, 6, 2}, false},
+		{[]uint64{2, 4}, false},
+		{[]uint64{0, 2, 6, 8, 12}, true},
+		{[]uint64{0, 2, 6, 8, 10}, false},
+	} {
+		if ok := tuple.Admissible(tc.pattern); ok != tc.ok {
+			t.Errorf("Admissible(%v) = %t, want %t", tc.pattern, ok, tc.ok)
+		}
+	}
+	if tuple.New(queue.PQueue{}, 0, 2, 4) != nil {
+		t.Error("New accepted inadmissible pattern")
+	}
+}
+
+// Compare Count against a brute force check of each prime.
+func TestCount(t *testing.T) {
+	const limit = 1e6
+	s := sieve.New(limit + 8)
+	isPrime := make([]bool, limit+9)
+	ps := prime.Primes(s)
+	for _, p := range ps {
+		isPrime[p] = true
+	}
+	for _, pat := range [][]uint64{tuple.Twin, tuple.Cousin, tuple.Sexy,
+		tuple.TripletA, tuple.TripletB, tuple.Quadruplet} {
+		var want uint64
+	pl:
+		for _, p := range ps {
+			if p > limit {
+				break
+			}
+			for _, o := range pat {
+				if !isPrime[p+o] {
+					continue pl
+				}
+			}
+			want++
+		}
+		got, ok := tuple.New(s, pat...).Count(0, limit)
+		if !ok || got != want {
+			t.Errorf("Count %v = %d, %t.  want %d", pat, got, ok, want)
+		}
+	}
+	if n, _ := tuple.New(s, tuple.Twin...).Count(0, limit); n != 8169 {
+		t.Errorf("%d twin primes below 1e6, want 8169", n)
+	}
+	tq := tuple.New(s, tuple.Quadruplet...)
+	if _, ok := tq.Count(0, tq.Limit()+1); ok {
+		t.Error("Count accepted max > Limit()")
+	}
+}
+
+// A wide range far above the limit of the generator of sieving primes,
+// checked against consecutive primes from a wheel sieve.
+func TestWide(t *testing.T) {
+	const min, max = 1e12, 1e12 + 3e6
+	tw := tuple.New(sieve.New(1e6+10), tuple.Twin...)
+	var want []uint64
+	var last uint64
+	wheel.New(max+2).Iterate(min, max+2, func(p uint64) (terminate bool) {
+		if p-last == 2 {
+			want = append(want, last)
+		}
+		last = p
+		return
+	})
+	var got []uint64
+	if !tw.Iterate(min, max, func(p uint64) (terminate bool) {
+		got = append(got, p)
+		return
+	}) {
+		t.Fatal("Iterate not ok")
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("%d twin primes, want %d", len(got), len(want))
+	}
+}
+
+func TestIterate(t *testing.T) {
+	want := []uint64{5, 11, 101, 191, 821, 1481, 1871, 2081, 3251, 3461}
+	var got []uint64
+	tuple.New(queue.PQueue{}, tuple.Quadruplet...).Iterate(0, 1e5,
+		func(p uint64) bool {
+			got = append(got, p)
+			return len(got) == len(want)
+		})
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i, p := range want {
+		if got[i] != p {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package wheel
+
+import "testing"
+
+func gcd(a, b uint64) uint64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// Each wheel grown should hold exactly the residues coprime to its
+// modulus, and index should find the least residue >= r.
+func TestRoll(t *testing.T) {
+	want := []uint64{2, 6, 30, 210, 2310, 30030, 510510}
+	for i, m := range want {
+		w := newWheel(m)
+		if w.modulus != m || len(w.primes) != i+1 {
+			t.Fatalf("newWheel(%d): modulus %d, primes %v", m, w.modulus, w.primes)
+		}
+		j := uint64(0)
+		for r := uint64(0); r < m; r++ {
+			if x := w.index(r); x != j {
+				t.Fatalf("modulus %d: index(%d) = %d, want %d", m, r, x, j)
+			}
+			if gcd(r, m) == 1 {
+				if uint64(w.res[j]) != r {
+					t.Fatalf("modulus %d: res[%d] = %d, want %d", m, j, w.res[j], r)
+				}
+				next := uint64(w.res[0]) + m
+				if j+1 < uint64(len(w.res)) {
+					next = uint64(w.res[j+1])
+				}
+				if uint64(w.gap[j]) != next-r {
+					t.Fatalf("modulus %d: gap[%d] = %d", m, j, w.gap[j])
+				}
+				j++
+			}
+		}
+		if j != uint64(len(w.res)) {
+			t.Fatalf("modulus %d: %d residues, want %d", m, len(w.res), j)
+		}
+	}
+	if w := newWheel(1e15); w.modulus != maxModulus || len(w.res) != 1658880 {
+		t.Errorf("modulus %d, φ %d", w.modulus, len(w.res))
+	}
+}
@@ -0,0 +1,254 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package wheel implements Pritchard's segmented wheel sieve, with a
+// dynamic wheel grown to suit the limit.
+//
+// A wheel of modulus M, a product of the first few primes, represents only
+// numbers coprime to M.  As in Pritchard's dynamic wheel sieve, the wheel
+// is grown as primes are found.  Starting with the wheel of 2, the least
+// number greater than 1 on the wheel is the next prime p.  The wheel is
+// rolled out p times to modulus M·p and the multiples p·f, for f on the
+// old wheel, are deleted.  Growth stops before M exceeds √n.
+//
+// Composites are then crossed off segment by segment.  For each sieving
+// prime p, the products p·f are enumerated where f is itself on the wheel,
+// so that only numbers on the wheel are ever touched.  A segment spans
+// whole turns of the wheel, about √n numbers.  The fraction of numbers on
+// a wheel of modulus near √n falls as 1/log log n, so the sieve takes
+// O(n/log log n) operations, sublinear in n, while memory for the wheel,
+// the sieving primes and one segment is O(√n).  See Paul Pritchard, "Fast
+// compact prime number sieves (among others)", J. Algorithms 4 (1983).
+//
+// To bound the wheel tables to about 10MB, the modulus is also limited to
+// 2·3·5·7·11·13·17·19 = 9699690.  Beyond n ≈ 9.4·10^13 the wheel stops
+// growing, and the operation count is O(n log log n) reduced by the
+// constant factor φ(M)/M ≈ 0.171.
+package wheel
+
+import (
+	"math"
+	"math/bits"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/xmath"
+)
+
+const (
+	// maxModulus is the largest wheel modulus, 19 primorial.
+	maxModulus = 9699690
+
+	// maxSegBits is the most bits in a segment, unless one turn of the
+	// wheel needs more.
+	maxSegBits = 1 << 21
+)
+
+// wheel is a wheel of numbers coprime to modulus.
+type wheel struct {
+	modulus uint64
+	primes  []uint64 // primes dividing modulus
+	res     []uint32 // residues coprime to modulus, increasing
+	gap     []uint8  // gap[j] = res[j+1] - res[j], wrapping around
+	coprime []uint64 // bitmap of residues coprime to modulus
+	rank    []uint32 // rank[i] is the number of residues < 64·i
+}
+
+// newWheel grows a wheel from the wheel of 2, while the modulus stays
+// within max.
+func newWheel(max uint64) *wheel {
+	w := &wheel{modulus: 2, primes: []uint64{2}, res: []uint32{1}}
+	for {
+		// the next prime is the least number > 1 on the wheel
+		p := w.modulus + 1
+		if len(w.res) > 1 {
+			p = uint64(w.res[1])
+		}
+		if m := w.modulus * p; m > max || m > maxModulus {
+			break
+		}
+		w.roll(p)
+	}
+	w.tables()
+	return w
+}
+
+// roll rolls the wheel out p times, to modulus·p, and deletes multiples
+// of p.
+func (w *wheel) roll(p uint64) {
+	m := w.modulus * p
+	on := make([]uint64, (m+63)/64)
+	for i := uint64(0); i < m; i += w.modulus {
+		for _, r := range w.res {
+			n := i + uint64(r)
+			on[n>>6] |= 1 << (n & 63)
+		}
+	}
+	for _, f := range w.res {
+		n := p * uint64(f)
+		on[n>>6] &^= 1 << (n & 63)
+	}
+	res := make([]uint32, 0, uint64(len(w.res))*(p-1))
+	for i, x := range on {
+		for ; x != 0; x &= x - 1 {
+			res = append(res, uint32(i*64+bits.TrailingZeros64(x)))
+		}
+	}
+	w.modulus, w.res, w.coprime = m, res, on
+	w.primes = append(w.primes, p)
+}
+
+// tables computes gap and rank for the final wheel.
+func (w *wheel) tables() {
+	if w.coprime == nil {
+		w.coprime = []uint64{2} // residue 1 of modulus 2
+	}
+	w.gap = make([]uint8, len(w.res))
+	for j := range w.res {
+		if j < len(w.res)-1 {
+			w.gap[j] = uint8(w.res[j+1] - w.res[j])
+		} else {
+			w.gap[j] = uint8(w.modulus + uint64(w.res[0]) - uint64(w.res[j]))
+		}
+	}
+	w.rank = make([]uint32, len(w.coprime))
+	n := 0
+	for i, x := range w.coprime {
+		w.rank[i] = uint32(n)
+		n += bits.OnesCount64(x)
+	}
+}
+
+// index returns the index in res of the least residue >= r, for
+// r < modulus, or len(res) if there is none.
+func (w *wheel) index(r uint64) uint64 {
+	return uint64(w.rank[r>>6]) +
+		uint64(bits.OnesCount64(w.coprime[r>>6]&(1<<(r&63)-1)))
+}
+
+// Sieve holds a wheel and sieving primes for iterating over primes up
+// to Lim.
+type Sieve struct {
+	Lim    uint64
+	w      *wheel
+	primes []uint32 // sieving primes after the wheel primes, up to √Lim
+}
+
+// New is the Sieve constructor.  It grows a wheel and finds sieving primes
+// up to the square root of n.  Primes are found when Iterate is called.
+//
+// n is limited to math.MaxInt64.
+func New(n uint64) *Sieve {
+	if n > math.MaxInt64 {
+		n = math.MaxInt64
+	}
+	r := xmath.FloorSqrt64(n)
+	s := &Sieve{Lim: n, w: newWheel(r)}
+	wp := s.w.primes
+	sieve.New(r).Iterate(wp[len(wp)-1]+1, r, func(p uint64) (terminate bool) {
+		s.primes = append(s.primes, uint32(p))
+		return
+	})
+	return s
+}
+
+// Limit satisfies prime.Generator.
+func (s *Sieve) Limit() uint64 {
+	return s.Lim
+}
+
+// Modulus returns the modulus of the wheel grown for the limit.
+func (s *Sieve) Modulus() uint64 {
+	return s.w.modulus
+}
+
+// Iterate iterates over primes between min and max inclusive, and calls
+// the visitor function for each prime.
+//
+// Iterate returns false if max > Limit(), otherwise it returns true.
+//
+// Memory used is one segment of whole turns of the wheel, and sieving
+// state for primes up to the square root of max.
+func (s *Sieve) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > s.Lim {
+		return false
+	}
+	w := s.w
+	for _, p := range w.primes {
+		if p >= min && p <= max && visitor(p) {
+			return true
+		}
+	}
+	if min > max {
+		return true
+	}
+	m := w.modulus
+	phi := uint64(len(w.res))
+	lo := min / m * m
+	periods := (max-lo)/m + 1
+	if r := xmath.FloorSqrt64(max)/m + 1; r < periods {
+		periods = r
+	}
+	if r := maxSegBits / phi; periods > r {
+		periods = r
+	}
+	segLen := periods * m
+	nBits := periods * phi
+	bm := make([]uint64, (nBits+63)/64)
+	// next multiplier f of each sieving prime, and its index on the wheel.
+	// f == 0 means the prime is not yet started.
+	next := make([]uint64, len(s.primes))
+	nj := make([]uint64, len(s.primes))
+	for ; lo <= max; lo += segLen {
+		hi := lo + segLen
+		for i := range bm {
+			bm[i] = 0
+		}
+		for i, p32 := range s.primes {
+			p := uint64(p32)
+			if p*p >= hi {
+				break
+			}
+			f, j := next[i], nj[i]
+			if f == 0 {
+				f = p
+				if c := (lo + p - 1) / p; c > f {
+					f = c
+				}
+				j = w.index(f % m)
+				f -= f % m
+				if j == phi {
+					j = 0
+					f += m
+				}
+				f += uint64(w.res[j])
+			}
+			for n := p * f; n < hi; n = p * f {
+				d := n - lo
+				k := d/m*phi + w.index(d%m)
+				bm[k>>6] |= 1 << (k & 63)
+				f += uint64(w.gap[j])
+				if j++; j == phi {
+					j = 0
+				}
+			}
+			next[i], nj[i] = f, j
+		}
+		for i, x := range bm {
+			for x = ^x; x != 0; x &= x - 1 {
+				k := uint64(i)*64 + uint64(bits.TrailingZeros64(x))
+				if k >= nBits {
+					break
+				}
+				n := lo + k/phi*m + uint64(w.res[k%phi])
+				if n > max {
+					return true
+				}
+				if n >= min && n > 1 && visitor(n) {
+					return true
+				}
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,75 @@
+package wheel_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/sprp"
+	"github.com/soniakeys/integer/prime/wheel"
+)
+
+// Small limits, where the wheel grows through 2, 6 and 30 and there are
+// few sieving primes or none.
+func TestSmall(t *testing.T) {
+	ref := sieve.New(300)
+	for n := uint64(0); n <= 300; n++ {
+		want := prime.Primes(ref, 0, n)
+		got := prime.Primes(wheel.New(n), 0, n)
+		if !reflect.DeepEqual(got, want) {
+			t.Fatalf("New(%d): %v, want %v", n, got, want)
+		}
+	}
+}
+
+// Ranges starting, ending, and crossing at multiples of the wheel modulus.
+func TestPeriods(t *testing.T) {
+	const n = 2e6
+	ref := sieve.New(n)
+	w := wheel.New(n)
+	m := w.Modulus()
+	if m != 210 { // 2310 > √n
+		t.Fatal("Modulus:", m)
+	}
+	for _, r := range [][2]uint64{{0, n}, {m - 1, m + 1}, {m, m},
+		{m, 2 * m}, {m + 1, 2*m - 1}, {m - 100, m + 100},
+		{66*m - 1, 66*m + 1}, {n - 100, n}} {
+		want := prime.Primes(ref, r[0], r[1])
+		got := prime.Primes(w, r[0], r[1])
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Primes(%d, %d): %d primes, want %d",
+				r[0], r[1], len(got), len(want))
+		}
+	}
+	if w.Iterate(0, n+1, func(uint64) bool { return false }) {
+		t.Error("Iterate accepted max > Limit")
+	}
+}
+
+// Windows where segments hold several turns of the wheel, crossing
+// segment boundaries so that sieving primes carry state from segment to
+// segment, and where the wheel has grown to its largest modulus.
+func TestWindow(t *testing.T) {
+	m := sprp.New()
+	for _, r := range []struct{ min, max, modulus uint64 }{
+		{4e10, 4e10 + 5e5, 30030},
+		{1e15, 1e15 + 2e5, 9699690},
+	} {
+		var want []uint64
+		for n := r.min + 1; n <= r.max; n += 2 {
+			if m.Prime64(n) {
+				want = append(want, n)
+			}
+		}
+		w := wheel.New(r.max)
+		if w.Modulus() != r.modulus {
+			t.Error("Modulus:", w.Modulus())
+		}
+		got := prime.Primes(w, r.min, r.max)
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Primes(%d, %d): %d primes, want %d",
+				r.min, r.max, len(got), len(want))
+		}
+	}
+}
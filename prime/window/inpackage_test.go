@@ -0,0 +1,30 @@
+package window
+
+import (
+	"math"
+	"testing"
+)
+
+// Sieving primes above 2^32 must not cross themselves off.  A sieve that
+// large is slow to build, so start is tested directly.
+func TestStart(t *testing.T) {
+	const large = 4294967311 // least prime above 2^32
+	for _, tc := range []struct {
+		n, p uint64
+		j    uint64
+		ok   bool
+	}{
+		{2, 3, 7, true},                                // p*p = 9
+		{10, 3, 2, true},                               // p*p below n
+		{large, large, 0, false},                       // window starts at p
+		{large - 5, large, 0, false},                   // window contains p
+		{large + 1, large, 0, false},                   // 2p is below p*p
+		{math.MaxUint64 - 2, large, large - 222, true}, // n+j past 2^64
+	} {
+		j, ok := start(tc.n, true, tc.p, tc.n%tc.p)
+		if ok != tc.ok || ok && j != tc.j {
+			t.Errorf("start(%d, %d) = %d, %t, want %d, %t",
+				tc.n, tc.p, j, ok, tc.j, tc.ok)
+		}
+	}
+}
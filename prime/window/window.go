@@ -0,0 +1,152 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package window finds primes in a short interval above a big number.
+//
+// The interval [n, n+l] is sieved by small primes from a sieve.Sieve.  The
+// starting offset for each small prime p is found from n mod p, computed
+// once per prime.  Survivors of the sieve are then confirmed with
+// big.Int.ProbablyPrime, run in parallel.
+package window
+
+import (
+	"math/big"
+	"math/bits"
+	"runtime"
+
+	"github.com/soniakeys/integer/prime/sieve"
+)
+
+// Visitor function passed to Iterate.
+//
+// Like prime.Visitor, a visitor function should return false to continue
+// iteration, true to terminate iteration.  The visitor may retain p.
+type Visitor func(p *big.Int) (terminate bool)
+
+// Window type holds a sieve of small primes used to sieve intervals.
+type Window struct {
+	Sieve *sieve.Sieve
+	// Reps is passed to big.Int.ProbablyPrime.  The zero value
+	// runs only the Baillie-PSW test.
+	Reps int
+}
+
+// New is a constructor that sieves small primes up to b.
+// (If you have a sieve already, you can just assign the Sieve member of
+// a zero value Window object.)
+func New(b uint64) *Window {
+	return &Window{Sieve: sieve.New(b)}
+}
+
+// Survivors returns offsets j in [0, l] such that n+j has no factor among
+// the sieving primes, other than itself.
+//
+// n must not be negative.
+func (w *Window) Survivors(n *big.Int, l uint64) []uint64 {
+	bm := make([]uint64, l/64+1)
+	words := n.Bits()
+	small := n.IsUint64()
+	n64 := n.Uint64()
+	// 0 and 1 are not prime
+	for j := uint64(0); small && n64+j < 2 && j <= l; j++ {
+		bm[j>>6] |= 1 << (j & 63)
+	}
+	w.Sieve.Iterate(2, w.Sieve.Lim, func(p uint64) (terminate bool) {
+		// r = n mod p
+		var r uint
+		for i := len(words) - 1; i >= 0; i-- {
+			_, r = bits.Div(r, uint(words[i]), uint(p))
+		}
+		j, ok := start(n64, small, p, uint64(r))
+		if !ok {
+			return
+		}
+		for ; j <= l; j += p {
+			bm[j>>6] |= 1 << (j & 63)
+		}
+		return
+	})
+	var s []uint64
+	for j := uint64(0); j <= l; j++ {
+		if bm[j>>6]&(1<<(j&63)) == 0 {
+			s = append(s, j)
+		}
+	}
+	return s
+}
+
+// start returns the offset from n of the first multiple of p to cross off,
+// given r = n mod p.  small is true if n fits in n64.
+//
+// Crossing starts no lower than p*p, so as not to cross off p itself.
+// Multiples below p*p have smaller factors and are crossed off by smaller
+// primes.  For p >= 2^32, p*p exceeds any uint64 n+j, and start returns
+// false if there is nothing to cross off.
+func start(n64 uint64, small bool, p, r uint64) (j uint64, ok bool) {
+	j = (p - r) % p
+	if !small {
+		return j, true
+	}
+	m, carry := bits.Add64(n64, j, 0)
+	if carry != 0 || m/p >= p {
+		return j, true // m >= p*p
+	}
+	if p >= 1<<32 {
+		return 0, false
+	}
+	return p*p - n64, true
+}
+
+// Iterate visits primes in the interval [n, n+l] in increasing order.
+//
+// n must not be negative.
+func (w *Window) Iterate(n *big.Int, l uint64, visitor Visitor) {
+	if n.Sign() < 0 {
+		return
+	}
+	s := w.Survivors(n, l)
+	// survivors less than the square of the sieve limit are prime.
+	var bound big.Int
+	bound.Mul(bound.SetUint64(w.Sieve.Lim), &bound)
+	isPrime := make([]bool, len(s))
+
+	jCh := make(chan int)
+	doneCh := make(chan int)
+	nCpu := runtime.GOMAXPROCS(0)
+	for i := 0; i < nCpu; i++ {
+		// workers
+		go func() {
+			var c, j big.Int
+			for x := range jCh {
+				c.Add(n, j.SetUint64(s[x]))
+				isPrime[x] = c.Cmp(&bound) < 0 || c.ProbablyPrime(w.Reps)
+			}
+			doneCh <- 0
+		}()
+	}
+	for x := range s {
+		jCh <- x
+	}
+	close(jCh)
+	for i := 0; i < nCpu; i++ {
+		<-doneCh
+	}
+
+	for x, j := range s {
+		if isPrime[x] {
+			p := new(big.Int).SetUint64(j)
+			if visitor(p.Add(n, p)) {
+				return
+			}
+		}
+	}
+}
+
+// Primes returns a slice containing primes in the interval [n, n+l].
+func (w *Window) Primes(n *big.Int, l uint64) (r []*big.Int) {
+	w.Iterate(n, l, func(p *big.Int) (terminate bool) {
+		r = append(r, p)
+		return
+	})
+	return
+}
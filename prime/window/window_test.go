@@ -0,0 +1,55 @@
+package window_test
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/prime/window"
+)
+
+// Small n, where results can be checked against a sieve.
+func TestSmall(t *testing.T) {
+	const limit = 1e5
+	ref := prime.Primes(sieve.New(limit))
+	w := window.New(100)
+	for _, tc := range []struct{ n, l uint64 }{
+		{0, 1000}, {1, 10}, {2, 0}, {97, 3}, {9000, 1000}, {99000, 1000},
+	} {
+		var want []uint64
+		for _, p := range ref {
+			if p >= tc.n && p <= tc.n+tc.l {
+				want = append(want, p)
+			}
+		}
+		got := w.Primes(new(big.Int).SetUint64(tc.n), tc.l)
+		if len(got) != len(want) {
+			t.Errorf("Primes(%d, %d) found %d primes, want %d",
+				tc.n, tc.l, len(got), len(want))
+			continue
+		}
+		for i, p := range got {
+			if p.Uint64() != want[i] {
+				t.Errorf("Primes(%d, %d) = %d, want %d",
+					tc.n, tc.l, p, want[i])
+				break
+			}
+		}
+	}
+}
+
+// 10^100 + 267 is the first prime after 10^100.  The next is 10^100 + 949.
+func TestGoogol(t *testing.T) {
+	g := new(big.Int).Exp(big.NewInt(10), big.NewInt(100), nil)
+	got := window.New(1e5).Primes(g, 1000)
+	want := []int64{267, 949}
+	if len(got) != len(want) {
+		t.Fatalf("found %d primes above 10^100, want %d", len(got), len(want))
+	}
+	for i, p := range got {
+		if d := p.Sub(p, g).Int64(); d != want[i] {
+			t.Errorf("prime %d = 10^100 + %d, want 10^100 + %d", i, d, want[i])
+		}
+	}
+}
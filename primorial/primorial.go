@@ -0,0 +1,129 @@
+// Copyright 2012 Sonia Keys
+// License MIT: http://www.opensource.org/licenses/MIT
+
+// Package primorial computes primorials and lcm(1..n) as products of prime
+// powers, and enumerates prime powers.
+package primorial
+
+import (
+	"math/big"
+	"sort"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/xmath"
+)
+
+// Primorial computes n#, the product of primes <= n, OEIS A034386.
+//
+// Result is computed in z, replacing the value of z.  z is returned.
+func Primorial(z *big.Int, n uint) *big.Int {
+	return PrimorialS(z, sieve.New(uint64(n)), n)
+}
+
+// PrimorialS computes n# using prime number sieve p.  PrimorialS returns
+// nil if p is too small.  Otherwise it leaves the result in z, replacing
+// the existing value of z, and returning z.
+func PrimorialS(z *big.Int, p *sieve.Sieve, n uint) *big.Int {
+	if uint64(n) > p.Lim {
+		return nil
+	}
+	return xmath.Product(z, prime.Primes(p, 2, uint64(n)))
+}
+
+// LCMRange computes lcm(1, 2, ..., n), OEIS A003418.
+//
+// Result is computed in z, replacing the value of z.  z is returned.
+func LCMRange(z *big.Int, n uint) *big.Int {
+	return LCMRangeS(z, sieve.New(uint64(n)), n)
+}
+
+// LCMRangeS computes lcm(1, 2, ..., n) using prime number sieve p.
+// LCMRangeS returns nil if p is too small.  Otherwise it leaves the result
+// in z, replacing the existing value of z, and returning z.
+//
+// The result is the product of the largest power of each prime that is
+// <= n.  Primes > √n contribute only the first power.
+func LCMRangeS(z *big.Int, p *sieve.Sieve, n uint) *big.Int {
+	if uint64(n) > p.Lim {
+		return nil
+	}
+	n64 := uint64(n)
+	rootN := uint64(xmath.FloorSqrt(n))
+	var factors []uint64
+	p.Iterate(2, rootN, func(p uint64) (terminate bool) {
+		factors = append(factors, largestPower(p, n64))
+		return
+	})
+	p.Iterate(rootN+1, n64, func(p uint64) (terminate bool) {
+		factors = append(factors, p)
+		return
+	})
+	return xmath.Product(z, factors)
+}
+
+// largestPower returns the largest power of p that is <= n.
+func largestPower(p, n uint64) uint64 {
+	q := p
+	for q <= n/p {
+		q *= p
+	}
+	return q
+}
+
+// Powers generates prime powers p^k, k >= 1, OEIS A246655.
+// It satisfies prime.Generator.
+type Powers struct {
+	Sieve *sieve.Sieve
+}
+
+// NewPowers is a constructor that generates the underlying prime sieve.
+// (If you have a sieve already, you can just assign the Sieve member of
+// a zero value Powers object.)
+func NewPowers(n uint64) *Powers {
+	return &Powers{Sieve: sieve.New(n)}
+}
+
+// Limit is the limit of the underlying sieve.
+func (pp *Powers) Limit() uint64 {
+	return pp.Sieve.Lim
+}
+
+// Iterate iterates over prime powers between min and max inclusive, in
+// increasing order, and calls the visitor function for each.
+//
+// Iterate returns false if max > Limit(), otherwise it returns true.
+//
+// Powers with k >= 2 are of primes <= √max and are few, so they are
+// collected and sorted first, then merged with the primes.
+func (pp *Powers) Iterate(min, max uint64, visitor prime.Visitor) bool {
+	if max > pp.Sieve.Lim {
+		return false
+	}
+	var higher []uint64
+	pp.Sieve.Iterate(2, xmath.FloorSqrt64(max), func(p uint64) (terminate bool) {
+		for q := p; q <= max/p; {
+			q *= p
+			if q >= min {
+				higher = append(higher, q)
+			}
+		}
+		return
+	})
+	sort.Slice(higher, func(i, j int) bool { return higher[i] < higher[j] })
+	i := 0
+	t := false
+	pp.Sieve.Iterate(min, max, func(p uint64) bool {
+		for ; i < len(higher) && higher[i] < p; i++ {
+			if t = visitor(higher[i]); t {
+				return true
+			}
+		}
+		t = visitor(p)
+		return t
+	})
+	for ; !t && i < len(higher); i++ {
+		t = visitor(higher[i])
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package primorial_test
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/soniakeys/integer/prime"
+	"github.com/soniakeys/integer/prime/sieve"
+	"github.com/soniakeys/integer/primorial"
+)
+
+func TestPrimorial(t *testing.T) {
+	// OEIS A034386
+	want := []int64{1, 1, 2, 6, 6, 30, 30, 210, 210, 210, 210, 2310, 2310,
+		30030, 30030, 30030, 30030, 510510, 510510, 9699690}
+	var z big.Int
+	for n, w := range want {
+		if primorial.Primorial(&z, uint(n)).Int64() != w {
+			t.Errorf("Primorial(%d) = %d, want %d", n, &z, w)
+		}
+	}
+	// check a large one against products of primes
+	const n = 10000
+	s := sieve.New(n)
+	r := big.NewInt(1)
+	for _, p := range prime.Primes(s) {
+		r.Mul(r, new(big.Int).SetUint64(p))
+	}
+	if primorial.PrimorialS(&z, s, n).Cmp(r) != 0 {
+		t.Error("PrimorialS", n)
+	}
+	if primorial.PrimorialS(&z, s, n+1) != nil {
+		t.Error("PrimorialS accepted small sieve")
+	}
+}
+
+func TestLCMRange(t *testing.T) {
+	// OEIS A003418
+	want := []int64{1, 1, 2, 6, 12, 60, 60, 420, 840, 2520, 2520, 27720,
+		27720, 360360, 360360, 360360, 720720, 12252240, 12252240}
+	var z big.Int
+	for n, w := range want {
+		if primorial.LCMRange(&z, uint(n)).Int64() != w {
+			t.Errorf("LCMRange(%d) = %d, want %d", n, &z, w)
+		}
+	}
+	// check a large one against repeated lcm
+	const n = 3000
+	s := sieve.New(n)
+	r := big.NewInt(1)
+	var g, k big.Int
+	for i := int64(2); i <= n; i++ {
+		k.SetInt64(i)
+		g.GCD(nil, nil, r, &k)
+		r.Mul(r, k.Quo(&k, &g))
+	}
+	if primorial.LCMRangeS(&z, s, n).Cmp(r) != 0 {
+		t.Error("LCMRangeS", n)
+	}
+	if primorial.LCMRangeS(&z, s, n+1) != nil {
+		t.Error("LCMRangeS accepted small sieve")
+	}
+}
+
+func TestPowers(t *testing.T) {
+	pp := primorial.NewPowers(1e5)
+	// OEIS A246655
+	want := []uint64{2, 3, 4, 5, 7, 8, 9, 11, 13, 16, 17, 19, 23, 25, 27,
+		29, 31, 32, 37, 41, 43, 47, 49, 53, 59, 61, 64, 67, 71, 73, 79, 81}
+	if got := prime.Primes(pp, 0, 81); !reflect.DeepEqual(got, want) {
+		t.Error("Powers(0, 81)", got)
+	}
+	// compare with trial factoring
+	isPower := func(n uint64) bool {
+		for p := uint64(2); p*p <= n; p++ {
+			if n%p == 0 {
+				for n%p == 0 {
+					n /= p
+				}
+				return n == 1
+			}
+		}
+		return n > 1
+	}
+	for _, r := range [][2]uint64{{0, 1e5}, {1000, 1024}, {1024, 1024}, {3, 2}} {
+		var w []uint64
+		for n := r[0]; n <= r[1]; n++ {
+			if isPower(n) {
+				w = append(w, n)
+			}
+		}
+		got := prime.Primes(pp, r[0], r[1])
+		if len(got) != len(w) || len(w) > 0 && !reflect.DeepEqual(got, w) {
+			t.Errorf("Powers(%d, %d): %d numbers, want %d",
+				r[0], r[1], len(got), len(w))
+		}
+	}
+	var n int
+	pp.Iterate(0, 1e5, func(uint64) bool {
+		n++
+		return n == 10
+	})
+	if n != 10 {
+		t.Error("Iterate did not terminate", n)
+	}
+	if pp.Iterate(0, 1e5+1, func(uint64) bool { return false }) {
+		t.Error("Iterate accepted max > Limit")
+	}
+}
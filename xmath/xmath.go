@@ -4,7 +4,10 @@
 // Package xmath contains functions useful for integer calculations.
 package xmath
 
-import "math/big"
+import (
+	"math"
+	"math/big"
+)
 
 // ProductSerialTreshold is a "knob" for tuning Product() performance.
 //
@@ -112,21 +115,22 @@ func FloorSqrt32(n uint32) uint32 {
 	return a
 }
 
-// FloorSqrt is an integer square root function.
+// FloorSqrt64 is an integer square root function.
+//
+// It corrects a float64 estimate, which is within one of the result.
 func FloorSqrt64(n uint64) uint64 {
-	b := (n + 1) / 2
-	if b >= n {
-		return n
+	const max = 1<<32 - 1 // floor(√MaxUint64)
+	r := uint64(math.Sqrt(float64(n)))
+	if r > max {
+		r = max
 	}
-	a := b
-	for {
-		b = (n/a + a) / 2
-		if b >= a {
-			break
-		}
-		a = b
+	for r*r > n {
+		r--
 	}
-	return a
+	for r < max && (r+1)*(r+1) <= n {
+		r++
+	}
+	return r
 }
 
 // TrailingZeros returns the number of trailing 0 bits in v.
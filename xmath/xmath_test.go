@@ -142,6 +142,12 @@ func TestFloorSqrt64(t *testing.T) {
 		{1<<20 + 1, 1 << 10},
 		{math.MaxUint32 - 1, math.MaxUint16},
 		{math.MaxUint64 - 1, math.MaxUint32},
+		{math.MaxUint64, math.MaxUint32},
+		{math.MaxUint32 * math.MaxUint32, math.MaxUint32},
+		{math.MaxUint32*math.MaxUint32 - 1, math.MaxUint32 - 1},
+		{1<<52 - 1, 1<<26 - 1},
+		{(1<<30 + 3) * (1<<30 + 3), 1<<30 + 3},
+		{(1<<30+3)*(1<<30+3) - 1, 1<<30 + 2},
 	}
 	for _, tc := range tcs {
 		if s := xmath.FloorSqrt64(tc.n); s != tc.s {